@@ -0,0 +1,324 @@
+package gonad
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/metaleap/go-util/dev/ps"
+	"github.com/metaleap/go-util/fs"
+)
+
+/*
+Synthesizes a PureScript project's worth of coreimp.json and
+externs.json fixtures (plus the bower.json and *.purs files
+that gonad discovers modules by), as a repeatable basis for
+the benchmarks in bench-pipeline_test.go. The module bodies
+mimic the shapes of purs' JS output: requires of imported
+modules, data constructors as IIFE-wrapped JS classes, type
+classes as dict ctors plus member accessors, instances, case
+expressions via instanceof, and curried functions nesting
+closures to some depth. Rather than hand-writing purs' JSON
+formats, fixtures are udevps structures marshaled as JSON.
+Sizes are configurable via -gonad.bench.* test flags.
+*/
+
+type benchGenOpts struct {
+	NumMods      int // modules, each importing up to NumImports of those before it
+	NumImports   int
+	NumAdts      int // per module, each with NumCtors ctors
+	NumCtors     int
+	NumClasses   int // per module, each with 2 members and 1 instance
+	NumFuncs     int // per module, each a curried function ClosureDepth args deep
+	ClosureDepth int
+}
+
+var benchGenFlags = benchGenOpts{}
+
+func init() {
+	flag.IntVar(&benchGenFlags.NumMods, "gonad.bench.mods", 100, "number of modules in the synthesized benchmark project")
+	flag.IntVar(&benchGenFlags.NumImports, "gonad.bench.imports", 4, "number of imports per synthesized module")
+	flag.IntVar(&benchGenFlags.NumAdts, "gonad.bench.adts", 4, "number of ADTs per synthesized module")
+	flag.IntVar(&benchGenFlags.NumCtors, "gonad.bench.ctors", 3, "number of ctors per synthesized ADT")
+	flag.IntVar(&benchGenFlags.NumClasses, "gonad.bench.classes", 2, "number of type classes per synthesized module")
+	flag.IntVar(&benchGenFlags.NumFuncs, "gonad.bench.funcs", 12, "number of functions per synthesized module")
+	flag.IntVar(&benchGenFlags.ClosureDepth, "gonad.bench.depth", 4, "closure nesting depth of synthesized functions")
+}
+
+// benchGenProject writes a synthesized project into a new temp dir, returning the paths of its bower.json, src and output dirs
+func benchGenProject(tb testing.TB, opts benchGenOpts) (bowerfilepath string, srcdirpath string, outdirpath string) {
+	dirpath := tb.TempDir()
+	srcdirpath, outdirpath = filepath.Join(dirpath, "src"), filepath.Join(dirpath, "output")
+	bowerfilepath = filepath.Join(dirpath, "bower.json")
+	bowerjson := fmt.Sprintf(`{"name": "gonad-bench", "Gonad": {"In": {"CoreFilesDirPath": %q}, "Out": {"GoDirSrcPath": %q, "GoNamespaceProj": "gonadbench"}}}`,
+		outdirpath, filepath.Join(dirpath, "gopath", "src"))
+	benchWriteFile(tb, bowerfilepath, []byte(bowerjson))
+	for i := 0; i < opts.NumMods; i++ {
+		gen := &benchGen{opts: opts, qName: fmt.Sprintf("Bench.M%d", i), ext: &udevps.Extern{EfVersion: "0.11.7"}, imp: &udevps.CoreImp{BuiltWith: "0.11.7"}}
+		for j := 1; j <= opts.NumImports && j <= i; j++ {
+			gen.imports = append(gen.imports, fmt.Sprintf("Bench.M%d", i-j))
+		}
+		gen.genModule()
+		benchWriteFile(tb, filepath.Join(srcdirpath, strReplDot2Slash.Replace(gen.qName)+".purs"), []byte("module "+gen.qName+" where\n"))
+		for filename, v := range map[string]interface{}{"coreimp.json": gen.imp, "externs.json": gen.ext} {
+			jsonbytes, err := json.Marshal(v)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			benchWriteFile(tb, filepath.Join(outdirpath, gen.qName, filename), jsonbytes)
+		}
+	}
+	return
+}
+
+func benchWriteFile(tb testing.TB, filepath string, data []byte) {
+	if err := ufs.WriteBinaryFile(filepath, data); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+type benchGen struct {
+	opts    benchGenOpts
+	qName   string
+	imports []string
+	ext     *udevps.Extern
+	imp     *udevps.CoreImp
+}
+
+func (me *benchGen) export(kind string, name string) {
+	exp := &udevps.ExternExport{}
+	switch kind {
+	case "type":
+		exp.TypeRef = []interface{}{"TypeRef", name, nil} // nil: all ctors exported
+	case "class":
+		exp.TypeClassRef = []interface{}{"TypeClassRef", name}
+	case "value":
+		exp.ValueRef = []interface{}{"ValueRef", map[string]interface{}{"Ident": name}}
+	case "instance":
+		exp.TypeInstanceRef = []interface{}{"TypeInstanceRef", map[string]interface{}{"Ident": name}}
+	}
+	me.ext.EfExports = append(me.ext.EfExports, exp)
+}
+
+func (me *benchGen) fn(name string, t *udevps.CoreTagType) {
+	me.imp.DeclEnv.Functions[name] = &udevps.CoreImpDeclFunc{Type: t}
+}
+
+func (me *benchGen) genModule() {
+	env := &me.imp.DeclEnv
+	env.Functions, env.TypeDefs, env.TypeSyns, env.Classes = map[string]*udevps.CoreImpDeclFunc{}, map[string]*udevps.CoreImpDeclTypeDef{}, map[string]*udevps.CoreImpDeclTypeSyn{}, map[string]*udevps.CoreImpDeclClass{}
+	me.ext.EfModuleName = strings.Split(me.qName, ".")
+	me.imp.Imps = [][]string{{"Prim"}}
+	for _, imp := range me.imports {
+		me.imp.Imps = append(me.imp.Imps, strings.Split(imp, "."))
+		me.imp.Body = append(me.imp.Body, bjLet(strReplDot2Underscore.Replace(imp), bjApp(bjVar("require"), bjStr("../"+imp))))
+	}
+	for k := 0; k < me.opts.NumAdts; k++ {
+		me.genAdt(k)
+	}
+	for k := 0; k < me.opts.NumClasses; k++ {
+		me.genClass(k)
+	}
+	for k := 0; k < me.opts.NumFuncs; k++ {
+		me.genFunc(k)
+	}
+}
+
+// data T<k> = T<k>C0 Int | T<k>C1 T<k> String | ..., plus a `size<k> :: T<k> -> Int` case-ing over it
+func (me *benchGen) genAdt(k int) {
+	tname := fmt.Sprintf("T%d", k)
+	tself, tdef := btCtor(me.qName+"."+tname), &udevps.CoreImpDeclTypeDef{}
+	tdef.Decl.DataType = &udevps.CoreImpDeclDataType{}
+	me.imp.DeclEnv.TypeDefs[tname] = tdef
+	me.export("type", tname)
+	var cases []*udevps.CoreImpAst
+	v := bjVar("v")
+	for c := 0; c < me.opts.NumCtors; c++ {
+		ctorname, ctortypes := fmt.Sprintf("%sC%d", tname, c), []*udevps.CoreTagType{btCtor("Prim.Int")}
+		if c > 0 {
+			ctortypes = []*udevps.CoreTagType{tself, btCtor("Prim.String")}
+		}
+		tdef.Decl.DataType.Ctors = append(tdef.Decl.DataType.Ctors, &udevps.CoreImpDeclCtor{Name: ctorname, Types: ctortypes})
+		// var T0C0 = (function () { function T0C0(value0) { this.value0 = value0; }; T0C0.create = function (value0) { return new T0C0(value0); }; return T0C0; })();
+		params, sets, createargs := []string{}, []*udevps.CoreImpAst{}, []*udevps.CoreImpAst{}
+		for a := range ctortypes {
+			param := fmt.Sprintf("value%d", a)
+			params, createargs = append(params, param), append(createargs, bjVar(param))
+			sets = append(sets, bjSet(bjDot(bjVar("this"), param), bjVar(param)))
+		}
+		create := bjFunc("", params[len(params)-1:], bjRet(bjNew(bjVar(ctorname), createargs...)))
+		for a := len(params) - 2; a >= 0; a-- { // curried, as purs does for all ctors with more than one arg
+			create = bjFunc("", params[a:a+1], bjRet(create))
+		}
+		me.imp.Body = append(me.imp.Body, bjLet(ctorname, bjApp(bjFunc("", nil,
+			bjFunc(ctorname, params, sets...),
+			bjSet(bjDot(bjVar(ctorname), "create"), create),
+			bjRet(bjVar(ctorname))))))
+		// if (v instanceof T0C1) { return size0(v.value0) + 1 | 0; };
+		ret := bjDot(v, "value0")
+		if c > 0 {
+			ret = bjBin("BitwiseOr", bjBin("Add", bjApp(bjVar(fmt.Sprintf("size%d", k)), bjDot(v, "value0")), bjInt(1)), bjInt(0))
+		}
+		cases = append(cases, bjIf(bjInstOf(v, bjVar(ctorname)), bjRet(ret)))
+	}
+	fname := fmt.Sprintf("size%d", k)
+	cases = append(cases, bjThrow(bjNew(bjVar("Error"), bjBin("Add",
+		bjStr(fmt.Sprintf("Failed pattern match at %s line %d, column 1 - line %d, column 1: ", me.qName, k+1, k+2)),
+		bjArr(bjDot(bjDot(v, "constructor"), "name"))))))
+	me.imp.Body = append(me.imp.Body, bjLet(fname, bjFunc("", []string{"v"}, cases...)))
+	me.fn(fname, btFn(tself, btCtor("Prim.Int")))
+	me.export("value", fname)
+}
+
+// class Cls<k> a where cls<k>a :: a -> a -> a; cls<k>b :: a -> Int, plus an `instance cls<k>Int :: Cls<k> Int`
+func (me *benchGen) genClass(k int) {
+	cname := fmt.Sprintf("Cls%d", k)
+	cqname, ta, members := me.qName+"."+cname, btVar("a"), []string{fmt.Sprintf("cls%da", k), fmt.Sprintf("cls%db", k)}
+	membertypes := []*udevps.CoreTagType{btFn(ta, btFn(ta, ta)), btFn(ta, btCtor("Prim.Int"))}
+	class, row := &udevps.CoreImpDeclClass{Args: []*udevps.CoreImpDeclTypeArg{{Name: "a"}}}, &udevps.CoreTagType{Tag: "REmpty"}
+	for m := len(members) - 1; m >= 0; m-- {
+		class.Members = append([]*udevps.CoreImpDeclClassMember{{Ident: members[m], Type: membertypes[m]}}, class.Members...)
+		row = &udevps.CoreTagType{Tag: "RCons", Text: members[m], Type0: membertypes[m], Type1: row}
+		// var cls0a = function (dict) { return dict.cls0a; };
+		me.imp.Body = append(me.imp.Body, bjLet(members[m], bjFunc("", []string{"dict"}, bjRet(bjDot(bjVar("dict"), members[m])))))
+		me.fn(members[m], btForAll("a", &udevps.CoreTagType{Tag: "ConstrainedType", Constr: &udevps.CoreConstr{Cls: cqname, Args: []*udevps.CoreTagType{ta}}, Type0: membertypes[m]}))
+		me.export("value", members[m])
+	}
+	me.imp.DeclEnv.Classes[cname] = class
+	me.imp.DeclEnv.TypeSyns[cname] = &udevps.CoreImpDeclTypeSyn{Type: btForAll("a", btApp(btCtor("Prim.Record"), row))} // the dict type, as purs pre-forms it for every class
+	me.export("class", cname)
+	// var Cls0 = function (cls0a, cls0b) { this.cls0a = cls0a; this.cls0b = cls0b; };
+	sets := []*udevps.CoreImpAst{}
+	for _, member := range members {
+		sets = append(sets, bjSet(bjDot(bjVar("this"), member), bjVar(member)))
+	}
+	me.imp.Body = append(me.imp.Body, bjLet(cname, bjFunc("", members, sets...)))
+	// var cls0Int = new Cls0(function (x) { return function (y) { return x + y | 0; }; }, function (x) { return x; });
+	iname := fmt.Sprintf("cls%dInt", k)
+	me.imp.Body = append(me.imp.Body, bjLet(iname, bjNew(bjVar(cname),
+		bjFunc("", []string{"x"}, bjRet(bjFunc("", []string{"y"}, bjRet(bjBin("BitwiseOr", bjBin("Add", bjVar("x"), bjVar("y")), bjInt(0)))))),
+		bjFunc("", []string{"x"}, bjRet(bjVar("x"))))))
+	me.imp.DeclEnv.ClassDicts = append(me.imp.DeclEnv.ClassDicts, map[string]map[string]*udevps.CoreImpDeclClassDict{
+		cqname: {iname: {InstanceTypes: []*udevps.CoreTagType{btCtor("Prim.Int")}}}})
+	me.fn(iname, btApp(btCtor(cqname), btCtor("Prim.Int")))
+	me.export("instance", iname)
+}
+
+// f<k> :: Int -> Int -> ... -> Int, nesting ClosureDepth closures, the innermost calling into an imported module and a class member
+func (me *benchGen) genFunc(k int) {
+	fname, tint := fmt.Sprintf("f%d", k), btCtor("Prim.Int")
+	params, ftype := make([]string, me.opts.ClosureDepth), tint
+	if len(params) == 0 {
+		params = make([]string, 1)
+	}
+	for d := range params {
+		params[d], ftype = fmt.Sprintf("v%d", d), btFn(tint, ftype)
+	}
+	var body *udevps.CoreImpAst = bjVar(params[0])
+	for _, param := range params[1:] {
+		body = bjBin("BitwiseOr", bjBin("Add", body, bjVar(param)), bjInt(0))
+	}
+	if len(me.imports) > 0 && k > 0 { // eg. Bench_M3.f1(v0)(v1)...
+		call := bjDot(bjVar(strReplDot2Underscore.Replace(me.imports[k%len(me.imports)])), fmt.Sprintf("f%d", k-1))
+		for _, param := range params {
+			call = bjApp(call, bjVar(param))
+		}
+		body = bjBin("BitwiseOr", bjBin("Add", body, call), bjInt(0))
+	}
+	if me.opts.NumClasses > 0 { // eg. cls0a(cls0Int)(...)(v0)
+		cls := k % me.opts.NumClasses
+		body = bjApp(bjApp(bjApp(bjVar(fmt.Sprintf("cls%da", cls)), bjVar(fmt.Sprintf("cls%dInt", cls))), body), bjVar(params[0]))
+	}
+	fn := bjRet(body)
+	for d := len(params) - 1; d > 0; d-- {
+		fn = bjRet(bjFunc("", []string{params[d]}, fn))
+	}
+	me.imp.Body = append(me.imp.Body, bjLet(fname, bjFunc("", params[:1], fn)))
+	me.fn(fname, ftype)
+	me.export("value", fname)
+}
+
+var strReplDot2Underscore = strings.NewReplacer(".", "_")
+
+func btCtor(qname string) *udevps.CoreTagType {
+	return &udevps.CoreTagType{Tag: "TypeConstructor", Text: qname}
+}
+
+func btVar(name string) *udevps.CoreTagType {
+	return &udevps.CoreTagType{Tag: "TypeVar", Text: name}
+}
+
+func btApp(left *udevps.CoreTagType, right *udevps.CoreTagType) *udevps.CoreTagType {
+	return &udevps.CoreTagType{Tag: "TypeApp", Type0: left, Type1: right}
+}
+
+func btFn(arg *udevps.CoreTagType, ret *udevps.CoreTagType) *udevps.CoreTagType {
+	return btApp(btApp(btCtor("Prim.Function"), arg), ret)
+}
+
+func btForAll(name string, t *udevps.CoreTagType) *udevps.CoreTagType {
+	return &udevps.CoreTagType{Tag: "ForAll", Text: name, Type0: t, Skolem: -1}
+}
+
+func bjVar(name string) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Var", Var: name}
+}
+
+func bjStr(s string) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "StringLiteral", StringLiteral: s}
+}
+
+func bjInt(i int) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "IntegerLiteral", IntegerLiteral: i}
+}
+
+func bjArr(vals ...*udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "ArrayLiteral", ArrayLiteral: vals}
+}
+
+func bjLet(name string, val *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "VariableIntroduction", VariableIntroduction: name, AstRight: val}
+}
+
+func bjSet(left *udevps.CoreImpAst, right *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Assignment", Assignment: left, AstRight: right}
+}
+
+func bjRet(val *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Return", Return: val}
+}
+
+func bjThrow(val *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Throw", Throw: val}
+}
+
+func bjIf(cond *udevps.CoreImpAst, then ...*udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "IfElse", IfElse: cond, AstThen: &udevps.CoreImpAst{AstTag: "Block", Block: then}}
+}
+
+func bjInstOf(val *udevps.CoreImpAst, ctor *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "InstanceOf", InstanceOf: val, AstRight: ctor}
+}
+
+func bjBin(op string, left *udevps.CoreImpAst, right *udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Binary", AstOp: op, Binary: left, AstRight: right}
+}
+
+func bjDot(obj *udevps.CoreImpAst, name string) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Indexer", Indexer: obj, AstRight: bjStr(name)}
+}
+
+func bjApp(callee *udevps.CoreImpAst, args ...*udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "App", App: callee, AstApplArgs: args}
+}
+
+func bjNew(ctor *udevps.CoreImpAst, args ...*udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Unary", AstOp: "New", Unary: bjApp(ctor, args...)}
+}
+
+func bjFunc(name string, params []string, body ...*udevps.CoreImpAst) *udevps.CoreImpAst {
+	return &udevps.CoreImpAst{AstTag: "Function", Function: name, AstFuncParams: params, AstBody: &udevps.CoreImpAst{AstTag: "Block", Block: body}}
+}
@@ -0,0 +1,89 @@
+package gonad
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+/*
+Benchmarks of each phase of the per-project pipeline (as
+driven by main) over a project synthesized by benchGenProject,
+eg. `go test -run - -bench Pipeline -gonad.bench.mods=800`.
+Every iteration starts from a freshly loaded project, runs all
+phases prior to the measured one untimed, then times just it.
+*/
+
+type benchPhase struct {
+	name string
+	run  func(*psBowerProject)
+}
+
+var benchPhases = []benchPhase{
+	{"load", (*psBowerProject).ensureModPkgIrMetas},
+	{"populate", (*psBowerProject).populateModPkgIrMetas},
+	{"prep", (*psBowerProject).prepModPkirAsts},
+	{"post", (*psBowerProject).reGenModPkirAsts},
+	{"codegen", benchCodeGen},                  // in-memory only, so not a prerequisite of "write"
+	{"write", (*psBowerProject).writeOutFiles}, // incl. codegen
+	{"symbols", (*psBowerProject).writeSymbolsFile},
+	{"facades", (*psBowerProject).writeFacades},
+}
+
+// benchLoadProj returns a session with a freshly loaded (but not yet processed) proj, with flag.ForceAll so that every module gets re-generated
+func benchLoadProj(tb testing.TB, bowerfilepath string, srcdirpath string) (sess *session) {
+	sess = newSession(Options{BowerJsonFilePath: bowerfilepath, SrcDirPath: srcdirpath, DepsDirPath: filepath.Dir(srcdirpath), ForceAll: true})
+	if err := sess.proj.loadFromJsonFile(); err != nil {
+		tb.Fatal(err)
+	}
+	sess.deps[""] = &sess.proj
+	sess.indexModPkgs()
+	if err := sess.proj.ensureOutDirs(); err != nil {
+		tb.Fatal(err)
+	}
+	return
+}
+
+func benchCodeGen(proj *psBowerProject) {
+	var buf bytes.Buffer
+	for _, m := range proj.Modules {
+		buf.Reset()
+		if err := m.irAst.writeAsGoTo(&buf); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	bowerfilepath, srcdirpath, _ := benchGenProject(b, benchGenFlags)
+	for i, phase := range benchPhases {
+		prereqs := benchPhases[:i]
+		b.Run(phase.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				sess := benchLoadProj(b, bowerfilepath, srcdirpath)
+				for _, prereq := range prereqs {
+					if prereq.name != "codegen" {
+						prereq.run(&sess.proj)
+					}
+				}
+				b.StartTimer()
+				phase.run(&sess.proj)
+			}
+		})
+	}
+	b.Run("all", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			b.StopTimer()
+			sess := benchLoadProj(b, bowerfilepath, srcdirpath)
+			b.StartTimer()
+			for _, phase := range benchPhases {
+				if phase.name != "codegen" {
+					phase.run(&sess.proj)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/go-forks/pflag"
+	"github.com/metaleap/gonad"
+)
+
+/*
+The gonad command: a thin wrapper around gonad.Compile,
+reporting failed modules (and warnings) on stderr.
+*/
+
+func main() {
+	var opts gonad.Options
+	var passtimings bool
+	var memlimitmb int
+	// args match those of purs and/or pulp where there's overlap, other config goes in bower.json's `Gonad` field (see `psBowerFile`)
+	pflag.StringVar(&opts.SrcDirPath, "src-path", "src", "Project-sources directory path")
+	pflag.StringVar(&opts.DepsDirPath, "dependency-path", "bower_components", "Dependencies directory path")
+	pflag.StringVar(&opts.BowerJsonFilePath, "bower-file", "bower.json", "Project file path (further configuration options possible in the Gonad field)")
+	pflag.BoolVar(&opts.NoPrefix, "no-prefix", false, "Do not include comment header")
+	pflag.BoolVar(&opts.Comments, "comments", false, "Include comments in the generated code")
+	pflag.BoolVar(&opts.ForceAll, "force", false, "Force-regenerate all *.go & *.json files, not just the outdated or missing ones")
+	pflag.BoolVar(&passtimings, "pass-timings", false, "Print how long each IR pass took (summed over all modules)")
+	pflag.BoolVar(&opts.VerifyIr, "verify-ir", false, "Check IR invariants after every prep step and IR pass, reporting the first one to break any")
+	pflag.BoolVar(&opts.FromAst, "from-ast", false, "Re-generate Go code from existing gonad.ast.json files (see Gonad.Out.DumpAst) instead of from coreimp.json")
+	pflag.StringSliceVar(&opts.DumpIr, "dump-ir", nil, "Write IR snapshots next to each re-generated module's gonad.json: after `prep`, after the final `post` pass, and/or after any named IR pass")
+	pflag.StringVar(&opts.PrintAfter, "print-after", "", "Print the IR of each re-generated module after the specified IR pass")
+	pflag.IntVar(&memlimitmb, "mem-limit", 0, "Soft memory ceiling in MiB, the GC working harder the closer we get (default: the GOMEMLIMIT env var, if any)")
+	pflag.Parse()
+	if memlimitmb > 0 {
+		debug.SetMemoryLimit(int64(memlimitmb) * 1024 * 1024)
+	}
+	if opts.FfiStubs = pflag.Arg(0) == "ffi-stubs"; pflag.NArg() > 0 && !opts.FfiStubs {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s (the only one currently supported being ffi-stubs)\n", pflag.Arg(0))
+		os.Exit(2)
+	}
+
+	opts.Out = os.Stdout
+	result, err := gonad.Compile(context.Background(), opts)
+	if result != nil {
+		for _, mod := range result.Modules {
+			for _, diag := range mod.Diagnostics {
+				if fmt.Fprintln(os.Stderr, diag.Error()); diag.Stack != "" {
+					fmt.Fprintln(os.Stderr, diag.Stack)
+				}
+			}
+		}
+		if passtimings {
+			for _, timing := range result.IrPassTimings {
+				fmt.Printf("IR pass %-24s%v\n", timing.Pass, timing.Time)
+			}
+		}
+		if !opts.FfiStubs {
+			fmt.Printf("Processing %d modules (re-generating %d) took me %v\n", len(result.Modules), result.NumReGenerated, result.Duration)
+		}
+	}
+	if err != nil {
+		if result == nil { // otherwise, all failures were just reported per module
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,374 @@
+package gonad
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaleap/go-util/fs"
+	"github.com/metaleap/go-util/slice"
+)
+
+/*
+The library entry point: Compile translates a PureScript
+project along with its bower_components to Go packages,
+just like the gonad command (a thin wrapper, see cmd/gonad)
+but reporting each module's outcome in the Result. Panics
+while processing one module (be they notImplErr diagnostics
+or outright bugs) fail just that module, not the whole run.
+All state of a run lives in its session, so any number of
+Compile calls may proceed concurrently (for distinct projects
+or at least distinct output directories).
+*/
+
+// session holds all the state of one Compile run, so that any number of them can proceed concurrently. Every psBowerProject points to it, and so does everything else via its modPkg
+type session struct {
+	proj psBowerProject
+	deps map[string]*psBowerProject // by dependency name, incl. the main proj as "" once all are loaded
+	flag Options
+
+	modPkgIdx struct { // set up by indexModPkgs once all deps are loaded
+		byQName map[string]*modPkg
+		byPName map[string]*modPkg
+	}
+
+	// set when the project-wide irMetaStamp is current (see irMetaStampIsCurrent): then each gonad.json gets loaded only once actually needed, see modPkg.ensureIrMeta
+	irMetasLoadLazily bool
+	// atomic: set by modPkg.ensureIrMeta on finding a lazily loaded gonad.json outdated or broken, so that Compile repeats the run non-lazily
+	irMetasLazyStale int32
+
+	// the enabled irPasses in run order, as set up by loadFromJsonFile via irPassesPipeline
+	irPassPipeline []*irPass
+	irPassRunTimes []int64 // per irPassPipeline entry, accumulated over all modules, in nanoseconds
+
+	// parsed from Gonad.Out.Facades by loadFromJsonFile, see writeFacades
+	facades []*facadeDecl
+
+	outMutex sync.Mutex // see printf
+}
+
+func newSession(opts Options) (me *session) {
+	if opts.Build == "" {
+		opts.Build = curGonadBuild()
+	}
+	me = &session{flag: opts, deps: map[string]*psBowerProject{}}
+	me.proj = psBowerProject{SrcDirPath: opts.SrcDirPath, DepsDirPath: opts.DepsDirPath, BowerJsonFilePath: opts.BowerJsonFilePath, sess: me}
+	return
+}
+
+// printf prints to flag.Out (if any), one call at a time: forAll ops call it in parallel
+func (me *session) printf(format string, args ...interface{}) {
+	if me.flag.Out != nil {
+		me.outMutex.Lock()
+		defer me.outMutex.Unlock()
+		fmt.Fprintf(me.flag.Out, format, args...)
+	}
+}
+
+// Options are the per-run settings of Compile, most others go in bower.json's `Gonad` field (see psBowerFile)
+type Options struct {
+	SrcDirPath        string // project-sources directory path, defaults to "src"
+	DepsDirPath       string // dependencies directory path, defaults to "bower_components"
+	BowerJsonFilePath string // project file path, defaults to "bower.json"
+
+	ForceAll   bool     // re-generate all *.go & *.json files, not just the outdated or missing ones
+	NoPrefix   bool     // omit the comment header of generated files
+	Comments   bool     // include comments in the generated code
+	VerifyIr   bool     // check IR invariants after every prep step and IR pass, failing a module on the first one to break any
+	FromAst    bool     // re-generate Go code from existing gonad.ast.json files (see Gonad.Out.DumpAst) instead of from coreimp.json
+	DumpIr     []string // write IR snapshots next to each re-generated module's gonad.json: after `prep`, after the final `post` pass, and/or after any named IR pass
+	PrintAfter string   // print the IR of each re-generated module after the named IR pass
+	FfiStubs   bool     // instead of generating code, add stubs for missing foreign imports to user-supplied FFI *.go files (see writeFfiStubs)
+
+	Build       string // stamped into every gonad.json (see irMetaStamp), any of which by another Build count as stale: defaults to this gonad build's, see curGonadBuild
+	FfiPkgsPath string // where gonad's default FFI packages (the gonadz dir of its source tree) are, defaults to the first GOPATH that has them
+
+	Out io.Writer // where PrintAfter's IR, FfiStubs' notes on the stubs added and Gonad.Out.MainDepLevel's dep levels get printed: nil to not print anything
+}
+
+// Result reports the outcome of a Compile run
+type Result struct {
+	Modules        []*ModuleResult // of the project and all its dependencies, sorted by QName
+	NumReGenerated int
+	Duration       time.Duration
+	IrPassTimings  []IrPassTiming // in pipeline order, each summed over all modules
+}
+
+type IrPassTiming struct {
+	Pass string
+	Time time.Duration
+}
+
+type ModuleResult struct {
+	QName       string // eg. Data.Maybe
+	Project     string // the name in the bower.json of the project or dependency the module belongs to
+	ReGenerated bool   // whether its Go package and gonad.json were (re)written, rather than current already
+	GoFilePath  string
+	Diagnostics []*Diagnostic
+}
+
+// Failed is true if any of the module's Diagnostics is more than a warning, in which case none of its outputs got written
+func (me *ModuleResult) Failed() bool {
+	for _, diag := range me.Diagnostics {
+		if !diag.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnostic is an error (or just a warning) pertaining to a module (or, with an empty Module, to the whole run). For PureScript constructs that gonad does not (yet) support, Category and Name tell which; for outright bugs, Stack is set for the report
+type Diagnostic struct {
+	Module   string
+	Warning  bool
+	Category string // eg. "CoreImp AST tag"
+	Name     string // eg. the unsupported tag
+	Message  string
+	Stack    string
+}
+
+func (me *Diagnostic) Error() string {
+	if me.Module == "" {
+		return me.Message
+	}
+	return me.Module + ": " + me.Message
+}
+
+// newDiagnostic turns what a panic was recovered with into a Diagnostic
+func newDiagnostic(modqname string, recovered interface{}) (diag *Diagnostic) {
+	diag = &Diagnostic{Module: modqname}
+	switch err := recovered.(type) {
+	case *notImplError:
+		diag.Category, diag.Name, diag.Message = err.Cat, err.Name, err.Error()
+	case runtime.Error:
+		diag.Message, diag.Stack = err.Error(), string(debug.Stack())
+	case error:
+		diag.Message = err.Error()
+	default:
+		diag.Message = fmt.Sprint(recovered)
+	}
+	return
+}
+
+// Compile translates the project configured by opts and bower.json. A non-nil error comes either from the run as a whole (then result is nil) or from failed modules: then result tells which, and the rest still got written
+func Compile(ctx context.Context, opts Options) (result *Result, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, err = nil, newDiagnostic("", recovered)
+		}
+	}()
+	starttime := time.Now()
+	if opts.SrcDirPath == "" {
+		opts.SrcDirPath = "src"
+	}
+	if opts.DepsDirPath == "" {
+		opts.DepsDirPath = "bower_components"
+	}
+	if opts.BowerJsonFilePath == "" {
+		opts.BowerJsonFilePath = "bower.json"
+	}
+	if opts.FfiPkgsPath == "" {
+		opts.FfiPkgsPath = defaultFfiPkgsDirPath()
+	}
+	if opts.FfiStubs {
+		opts.ForceAll = true // stubs need every irMeta fresh from its coreimp (but nothing else gets written)
+	}
+	if result, err = newSession(opts).compile(ctx, starttime, true); result == nil && err == nil {
+		// a lazily loaded gonad.json was stale: so it and all its importers' outputs get re-generated in a non-lazy run, see modPkg.ensureIrMeta
+		result, err = newSession(opts).compile(ctx, starttime, false)
+	}
+	return
+}
+
+// compile is all of Compile but the Options defaults. With lazyok, it returns neither result nor err if the run needs repeating non-lazily, see session.irMetasLazyStale
+func (me *session) compile(ctx context.Context, starttime time.Time, lazyok bool) (result *Result, err error) {
+	if !ufs.DirExists(me.proj.DepsDirPath) {
+		return nil, fmt.Errorf("No such `dependency-path` directory: %s", me.proj.DepsDirPath)
+	} else if !ufs.DirExists(me.proj.SrcDirPath) {
+		return nil, fmt.Errorf("No such `src-path` directory: %s", me.proj.SrcDirPath)
+	} else if err = me.proj.loadFromJsonFile(); err != nil {
+		return nil, err
+	}
+	do := mainWorker{sess: me}
+	var mutex sync.Mutex
+	ufs.WalkDirsIn(me.proj.DepsDirPath, func(reldirpath string) bool {
+		do.Add(1)
+		go do.checkIfDepDirHasBowerFile(&mutex, reldirpath)
+		return true
+	})
+	do.Wait()
+	if err = do.forAllDeps(ctx, do.loadDepFromBowerFile); err != nil {
+		return nil, err
+	}
+	me.deps[""] = &me.proj // from now on, all deps and the main proj are handled in parallel and equivalently
+	me.confirmNoOutDirConflicts()
+	me.indexModPkgs()
+	if err = me.checkFacadeMods(); err != nil {
+		return nil, err
+	}
+	if me.irMetasLoadLazily = lazyok && !me.flag.ForceAll && me.irMetaStampIsCurrent(); !me.irMetasLoadLazily {
+		_ = os.Remove(me.irMetaStampFilePath()) // as long as this run hasn't completed, the next one can't go lazy either
+	}
+	if err = do.forAllDeps(ctx, (*psBowerProject).ensureModPkgIrMetas); err != nil {
+		return nil, err
+	}
+	me.reGenDependentsOfStaleIrMetas()
+	if me.flag.FfiStubs {
+		if err = do.forAllDeps(ctx, (*psBowerProject).populateModPkgIrMetas); err != nil {
+			return nil, err
+		}
+		for _, dep := range me.deps {
+			dep.writeFfiStubs(me.flag.FfiPkgsPath)
+		}
+		return me.compileResult(starttime)
+	}
+	for _, dep := range me.deps {
+		if err = dep.ensureOutDirs(); err != nil {
+			return nil, err
+		}
+	}
+	if err = me.ensureDefaultFfiPkgs(); err != nil {
+		return nil, err
+	}
+	for _, phase := range []func(*psBowerProject){
+		(*psBowerProject).populateModPkgIrMetas,
+		(*psBowerProject).prepModPkirAsts,
+		(*psBowerProject).reGenModPkirAsts,
+		(*psBowerProject).writeOutFiles,
+		(*psBowerProject).writeSymbolsFile,
+		(*psBowerProject).writeFacades,
+	} {
+		if err = do.forAllDeps(ctx, phase); err != nil {
+			return nil, err
+		}
+	}
+	if atomic.LoadInt32(&me.irMetasLazyStale) != 0 {
+		return nil, nil
+	}
+	if result, err = me.compileResult(starttime); err == nil { // with failed modules, the project isn't current yet, so no stamp (nor test main) for this run
+		if err = me.writeIrMetaStampFile(); err == nil && me.proj.BowerJsonFile.Gonad.Out.MainDepLevel > 0 {
+			err = me.writeTestMainGo(me.allPkgImpPaths())
+		}
+	}
+	return
+}
+
+// compileResult collects all modules' outcomes, with an error summarizing the failed modules (if any)
+func (me *session) compileResult(starttime time.Time) (result *Result, err error) {
+	numfailed := 0
+	result = &Result{Duration: time.Since(starttime)}
+	for _, dep := range me.deps {
+		for _, mod := range dep.Modules {
+			modresult := &ModuleResult{QName: mod.qName, Project: dep.BowerJsonFile.Name, GoFilePath: mod.gopkgfilepath, Diagnostics: mod.diags}
+			if modresult.Failed() {
+				numfailed++
+			} else if modresult.ReGenerated = mod.reGenIr || me.flag.ForceAll; modresult.ReGenerated {
+				result.NumReGenerated++
+			}
+			result.Modules = append(result.Modules, modresult)
+		}
+	}
+	sort.Slice(result.Modules, func(i int, j int) bool { return result.Modules[i].QName < result.Modules[j].QName })
+	for i, pass := range me.irPassPipeline {
+		if pass.prep {
+			continue // not timed separately from the "prep" stage
+		}
+		result.IrPassTimings = append(result.IrPassTimings, IrPassTiming{Pass: pass.name, Time: time.Duration(atomic.LoadInt64(&me.irPassRunTimes[i]))})
+	}
+	if numfailed > 0 {
+		for _, modresult := range result.Modules {
+			if modresult.Failed() {
+				err = fmt.Errorf("%d of %d modules failed, the first being %v", numfailed, len(result.Modules), modresult.Diagnostics[len(modresult.Diagnostics)-1])
+				break
+			}
+		}
+	}
+	return
+}
+
+func (me *session) confirmNoOutDirConflicts() {
+	gooutdirs := map[string]*psBowerProject{}
+	for _, dep := range me.deps {
+		for _, mod := range dep.Modules {
+			modoutdirpath := filepath.Join(dep.GoOut.PkgDirPath, mod.goOutDirPath)
+			if prev := gooutdirs[modoutdirpath]; prev == nil {
+				gooutdirs[modoutdirpath] = dep
+			} else {
+				panic(fmt.Sprintf("Conflicting Go output packages: both '%s' and '%s' want to write to %s", prev.BowerJsonFile.Name, dep.BowerJsonFile.Name, modoutdirpath))
+			}
+		}
+	}
+}
+
+func (me *session) allPkgImpPaths() map[string]bool {
+	allpkgimppaths := map[string]bool{}
+	for _, dep := range me.deps {
+		for _, mod := range dep.Modules {
+			allpkgimppaths[mod.impPath()] = mod.reGenIr
+		}
+	}
+	return allpkgimppaths
+}
+
+func (me *session) writeTestMainGo(allpkgimppaths map[string]bool) (err error) {
+	w := &bytes.Buffer{}
+	fmt.Fprintln(w, "package main\n\nimport (")
+
+	// temporary commandline option to only import a sub-set of packages
+	okpkgs := []string{}
+	for i := 0; i < me.proj.BowerJsonFile.Gonad.Out.MainDepLevel; i++ {
+		thisok := []string{}
+		for _, dep := range me.deps {
+			for _, mod := range dep.Modules {
+				if modimppath := mod.impPath(); !uslice.StrHas(okpkgs, modimppath) {
+					isthisok := true
+					mod.ensureIrMeta()
+					for _, imp := range mod.irMeta.Imports {
+						if imp.emitted && !uslice.StrHas(okpkgs, imp.ImpPath) {
+							if !(imp.PsModQName == "" || strings.HasPrefix(imp.ImpPath, prefixDefaultFfiPkgImpPath)) {
+								isthisok = false
+								break
+							}
+						}
+					}
+					if isthisok {
+						me.printf("dep level #%d\t%s\n", i+1, modimppath)
+						thisok = append(thisok, modimppath)
+					}
+				}
+			}
+		}
+		okpkgs = append(okpkgs, thisok...)
+	}
+	for pkgimppath, _ := range allpkgimppaths {
+		if !uslice.StrHas(okpkgs, pkgimppath) {
+			delete(allpkgimppaths, pkgimppath)
+		}
+	}
+
+	//	we sort them
+	pkgimppaths := sort.StringSlice{}
+	for pkgimppath, _ := range allpkgimppaths {
+		pkgimppaths = append(pkgimppaths, pkgimppath)
+	}
+	sort.Strings(pkgimppaths)
+	for _, pkgimppath := range pkgimppaths {
+		if _, err = fmt.Fprintf(w, "\t_ %q\n", pkgimppath); err != nil {
+			return
+		}
+	}
+	if _, err = fmt.Fprintln(w, ")\n\nfunc main() { println(\"Looks like this compiled just fine!\") }"); err == nil {
+		err = ufs.WriteTextFile(filepath.Join(me.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, me.proj.GoOut.PkgDirPath, "check-if-all-gonad-generated-packages-compile.go"), w.String())
+	}
+	return
+}
@@ -0,0 +1,221 @@
+package gonad
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+End-to-end conformance suite: every testdata/conformance/<feature>/<program>
+dir pairs a PureScript program (its src, plus the coreimp.json and externs.json
+files in output that purs made from it) with the stdout it prints when compiled
+by purs' JS backend. The lib dir holds the (pared-down) bower_components and
+their outputs that all programs import.
+
+For each program, gonad (the test executable re-run to just Compile, see
+TestMain) translates it and its deps to Go in a fresh GOPATH, the local Go
+toolchain builds that together with a main package invoking the program's main,
+and the resulting executable's stdout must match. As that takes a while, only
+the programs of conformanceDefault run unless -conformance is given, and the
+suite ends by summarizing per feature which programs pass and in which stage
+(gonad, build, run or stdout) others fail. Programs in conformanceKnownFailing
+must fail in just the stage given there: any other failure (or a pass) fails
+the suite, so that regressions show and the list stays current.
+Before all that, conformancePreflight has the Go toolchain build gonad's
+default FFI packages and the generated packages of the lib: if even that fails
+(eg. a go command without GOPATH mode), the suite fails right there, once,
+rather than every program in its build stage.
+*/
+
+var conformanceRun = flag.Bool("conformance", false, "run all programs of the end-to-end conformance suite in testdata/conformance, not just those of conformanceDefault (requires the go command)")
+
+const (
+	conformanceEnvRunGonad = "GONAD_CONFORMANCE_RUN_GONAD" // set for the gonad child processes of TestConformance
+	conformanceGoNamespace = "conformance"
+	conformanceTimeout     = time.Minute
+)
+
+// the programs run without -conformance: quick ones, known to pass
+var conformanceDefault = map[string]bool{"adts/enums": true, "ffi/user": true}
+
+// by feature/program, the stage each is known to fail in, and why
+var conformanceKnownFailing = map[string]struct{ stage, why string }{
+	"adts/shapes":        {"build", "ctor applications left as calls of the JS ctor's curried `create`, on an undeclared `rect`"},
+	"classes/superclass": {"build", "type-class dicts typed `𝒈.𝑻`, so member and super-class accesses on them don't compile"},
+	"loops/tco":          {"build", "TCO loops become nested `func ᵒtco_loop` decls"},
+	"newtypes/wrap":      {"build", "newtype values not converted to and from the wrapped type, eg. a `Name` returned as `string`"},
+	"patterns/literals":  {"build", "bind continuations typed `func(interface{}) interface{}` rather than `func(𝒈.𝑻) Effect.Effect`"},
+	"patterns/maybe":     {"build", "ctor applications left as calls of the JS ctor's curried `create`, on an undeclared `some`"},
+	"records/fields":     {"build", "record literals of type `interface{/*EMPTY*/}`, which doesn't parse"},
+}
+
+type conformanceResult struct {
+	feature string
+	program string
+	stage   string // where it failed, or "" if it passed
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(conformanceEnvRunGonad) != "" {
+		if _, err := Compile(context.Background(), Options{}); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestConformance(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command found: " + err.Error())
+	}
+	progdirpaths, err := filepath.Glob(filepath.Join("testdata", "conformance", "*", "*", "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(progdirpaths) == 0 {
+		t.Fatal("no programs in testdata/conformance")
+	}
+	if err = conformancePreflight(t); err != nil {
+		t.Fatalf("infrastructure: gonad's default FFI packages and the lib's generated packages don't build, so no program could: %v", err)
+	}
+	var results []conformanceResult
+	var mutex sync.Mutex
+	t.Run("programs", func(t *testing.T) {
+		for _, stdoutfilepath := range progdirpaths {
+			progdirpath := filepath.Dir(stdoutfilepath)
+			result := conformanceResult{feature: filepath.Base(filepath.Dir(progdirpath)), program: filepath.Base(progdirpath)}
+			name := result.feature + "/" + result.program
+			if !(*conformanceRun || conformanceDefault[name]) {
+				continue
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				var err error
+				result.stage, err = conformanceCheck(t, progdirpath)
+				if known, isknown := conformanceKnownFailing[name]; !isknown && err != nil {
+					t.Errorf("%s: %v", result.stage, err)
+				} else if isknown && result.stage != known.stage {
+					t.Errorf("known to fail in the %s stage (%s), but got: %s %v", known.stage, known.why, result.stage, err)
+				} else if isknown {
+					t.Logf("known failing: %s", known.why)
+				}
+				mutex.Lock()
+				results = append(results, result)
+				mutex.Unlock()
+			})
+		}
+	})
+	t.Log("\n" + conformanceSummary(results))
+}
+
+// conformancePreflight translates just the lib, then builds its generated packages together with the default FFI packages as deployed by ensureDefaultFfiPkgs, which all generated code imports
+func conformancePreflight(t *testing.T) (err error) {
+	dirpath, gosrcdirpath := conformanceSetup(t, "")
+	ffipkgsdirpath, err := filepath.Abs(dirNameDefaultFfiPkgs)
+	if err != nil {
+		return
+	}
+	var result *Result
+	if result, err = Compile(context.Background(), Options{BowerJsonFilePath: filepath.Join(dirpath, "bower.json"), SrcDirPath: filepath.Join(dirpath, "src"),
+		DepsDirPath: filepath.Join(dirpath, "bower_components"), FfiPkgsPath: ffipkgsdirpath}); err != nil {
+		return
+	}
+	imppaths := []string{impPathDefaultFfiRoot + "/..."}
+	for _, mod := range result.Modules {
+		imppaths = append(imppaths, conformanceGoNamespace+"/"+strReplDot2Slash.Replace(mod.QName))
+	}
+	env := append(os.Environ(), "GOPATH="+filepath.Dir(gosrcdirpath), "GO111MODULE=off", "GOFLAGS=")
+	ctx, cancel := context.WithTimeout(context.Background(), conformanceTimeout)
+	defer cancel()
+	_, err = conformanceExec(ctx, gosrcdirpath, env, "go", append([]string{"build"}, imppaths...)...)
+	return
+}
+
+// conformanceSetup copies the lib's and the program's (if any) inputs into a temp dir, next to a bower.json with outputs going to that temp dir's gopath
+func conformanceSetup(t *testing.T, progdirpath string) (dirpath string, gosrcdirpath string) {
+	dirpath, libdirpath := t.TempDir(), filepath.Join("testdata", "conformance", "lib")
+	gosrcdirpath = filepath.Join(dirpath, "gopath", "src")
+	goldenCopyDir(t, filepath.Join(libdirpath, "bower_components"), filepath.Join(dirpath, "bower_components"))
+	goldenCopyDir(t, filepath.Join(libdirpath, "output"), filepath.Join(dirpath, "output"))
+	if progdirpath == "" { // just the lib
+		benchWriteFile(t, filepath.Join(dirpath, "src", ".keep"), nil)
+	} else {
+		goldenCopyDir(t, filepath.Join(progdirpath, "output"), filepath.Join(dirpath, "output"))
+		goldenCopyDir(t, filepath.Join(progdirpath, "src"), filepath.Join(dirpath, "src"))
+	}
+	benchWriteFile(t, filepath.Join(dirpath, "bower.json"), []byte(fmt.Sprintf(`{"name": "gonad-conformance", "Gonad": {"In": {"CoreFilesDirPath": %q}, "Out": {"GoDirSrcPath": %q, "GoNamespaceProj": %q}}}`,
+		filepath.Join(dirpath, "output"), gosrcdirpath, conformanceGoNamespace)))
+	return
+}
+
+// conformanceCheck translates, builds and runs the program, returning the stage that failed (if any) with its error
+func conformanceCheck(t *testing.T, progdirpath string) (stage string, err error) {
+	dirpath, gosrcdirpath := conformanceSetup(t, progdirpath)
+	goldenCopyDir(t, dirNameDefaultFfiPkgs, filepath.Join(gosrcdirpath, "github.com", "metaleap", "gonad", dirNameDefaultFfiPkgs)) // where defaultFfiPkgsDirPath looks for them
+	benchWriteFile(t, filepath.Join(gosrcdirpath, conformanceGoNamespace+"-main", "main.go"), []byte(fmt.Sprintf(
+		"package main\n\nimport psmain %q\n\nfunc main() { psmain.Main() }\n", conformanceGoNamespace+"/Main")))
+	env := append(os.Environ(), "GOPATH="+filepath.Dir(gosrcdirpath), "GO111MODULE=off", "GOFLAGS=")
+
+	ctx, cancel := context.WithTimeout(context.Background(), conformanceTimeout)
+	defer cancel()
+	exefilepath := filepath.Join(dirpath, "main")
+	var stdout []byte
+	if _, err = conformanceExec(ctx, dirpath, append(env, conformanceEnvRunGonad+"=1"), os.Args[0]); err != nil {
+		stage = "gonad"
+	} else if _, err = conformanceExec(ctx, dirpath, env, "go", "build", "-o", exefilepath, conformanceGoNamespace+"-main"); err != nil {
+		stage = "build"
+	} else if stdout, err = conformanceExec(ctx, dirpath, env, exefilepath); err != nil {
+		stage = "run"
+	} else if want := goldenReadFile(t, filepath.Join(progdirpath, "stdout")); !bytes.Equal(stdout, want) {
+		stage, err = "stdout", fmt.Errorf("want:\n%s\ngot:\n%s", want, stdout)
+	}
+	return
+}
+
+func conformanceExec(ctx context.Context, dirpath string, env []string, cmdname string, args ...string) (stdout []byte, err error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cmdname, args...)
+	cmd.Dir, cmd.Env, cmd.Stderr = dirpath, env, &stderr
+	if stdout, err = cmd.Output(); err != nil {
+		err = fmt.Errorf("%s: %v\n%s%s", filepath.Base(cmdname), err, stdout, stderr.Bytes())
+	}
+	return
+}
+
+// conformanceSummary tabulates per feature how many of its programs pass, and which fail in what stage
+func conformanceSummary(results []conformanceResult) string {
+	var buf bytes.Buffer
+	sort.Slice(results, func(i int, j int) bool {
+		return results[i].feature < results[j].feature || (results[i].feature == results[j].feature && results[i].program < results[j].program)
+	})
+	numpassed := 0
+	for i := 0; i < len(results); {
+		feature, passed, failed := results[i].feature, 0, []string{}
+		for ; i < len(results) && results[i].feature == feature; i++ {
+			if results[i].stage == "" {
+				passed++
+			} else {
+				failed = append(failed, results[i].program+" ("+results[i].stage+")")
+			}
+		}
+		numpassed += passed
+		fmt.Fprintf(&buf, "%-12s %d/%d", feature, passed, passed+len(failed))
+		if len(failed) > 0 {
+			fmt.Fprintf(&buf, "\tfailing: %s", strings.Join(failed, ", "))
+		}
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&buf, "%-12s %d/%d\n", "TOTAL", numpassed, len(results))
+	return buf.String()
+}
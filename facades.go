@@ -0,0 +1,769 @@
+package gonad
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/metaleap/go-util/fs"
+	"github.com/metaleap/go-util/str"
+)
+
+/*
+Facade packages: opt-in, hand-friendly Go wrappers around
+selected exports of the generated packages, for Go callers
+that shouldn't need to know gonad's encoding. Each entry in
+bower.json's Gonad.Out.Facades names one export, optionally
+instantiating its type variables (in the order of its foralls,
+like PureScript's visible type application) and/or renaming it:
+	"My.Mod.frob"
+	"My.Mod.frobAll @Int @(Array String) as FrobInts"
+(Unqualified type names are those of Prim, of My.Mod, or else
+of the one module imported by My.Mod that exports such a type.)
+All entries of one module make up its facade package, written
+to facade/my/mod (lower-cased, as is its package name) under
+the project's Gonad.Out.GoNamespaceProj.
+
+Every facade func takes all args of its export at once, passes
+the instance dictionaries that its (instantiated) constraints
+call for, and converts between Go-native types and gonad's
+representations: Int to int, Array to slices, Maybe to pointers
+(or, for results, to an additional bool) and Either results to
+an additional error. All other types are passed as generated.
+(Like JS's `x|0`, int args wrap around beyond int32's range.)
+
+Entries naming no module of the project fail the Compile right
+away, all other bad ones fail (with diagnostics) their module.
+*/
+
+const dirNameFacades = "facade"
+
+// facadeDecl is one parsed Gonad.Out.Facades entry
+type facadeDecl struct {
+	entry    string // as in bower.json, for error messages
+	modQName string
+	psName   string
+	typeArgs irMTypeRefs // instantiating the export's foralls, in order
+	goName   string
+}
+
+// facadeDeclError is a bad Gonad.Out.Facades entry, as opposed to a bug in facadeGen: see facadeGen.file
+type facadeDeclError struct{ msg string }
+
+func (me *facadeDeclError) Error() string { return me.msg }
+
+func (me *facadeDecl) errorf(format string, args ...interface{}) error {
+	return &facadeDeclError{fmt.Sprintf("bad `Gonad{Out{Facades}}` entry %q: "+format, append([]interface{}{me.entry}, args...)...)}
+}
+
+// parseFacadeDecls parses all Gonad.Out.Facades entries, see parseFacadeDecl
+func parseFacadeDecls(entries []string) (decls []*facadeDecl, err error) {
+	for _, entry := range entries {
+		decl := &facadeDecl{entry: entry}
+		if err = decl.parse(); err != nil {
+			return nil, err
+		}
+		decls = append(decls, decl)
+	}
+	return
+}
+
+// parse expects our entry in the form `My.Mod.export [@Type ...] [as GoName]`
+func (me *facadeDecl) parse() (err error) {
+	toks := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ", "@", " @ ").Replace(me.entry))
+	if len(toks) == 0 {
+		return me.errorf("empty")
+	} else if i := strings.LastIndex(toks[0], "."); i <= 0 || i == len(toks[0])-1 || ustr.BeginsUpper(toks[0][i+1:]) {
+		return me.errorf("not a module-qualified value name: %s", toks[0])
+	} else {
+		me.modQName, me.psName = toks[0][:i], toks[0][i+1:]
+	}
+	pos := 1
+	for pos < len(toks) && toks[pos] == "@" {
+		var typearg *irMTypeRef
+		if typearg, pos, err = me.parseTypeAtom(toks, pos+1); err != nil {
+			return
+		}
+		me.typeArgs = append(me.typeArgs, typearg)
+	}
+	if me.goName = facadeGoName(me.psName); pos < len(toks) {
+		if pos != len(toks)-2 || toks[pos] != "as" {
+			return me.errorf("expected `as GoName` instead of: %s", strings.Join(toks[pos:], " "))
+		}
+		me.goName = toks[pos+1]
+	}
+	if !facadeIsExportedIdent(me.goName) {
+		return me.errorf("not an exported Go identifier: %s", me.goName)
+	}
+	return
+}
+
+// parseType parses juxtaposed type atoms (as type applications) from toks[pos:], up to a closing paren
+func (me *facadeDecl) parseType(toks []string, pos int) (t *irMTypeRef, _ int, err error) {
+	for pos < len(toks) && toks[pos] != ")" {
+		var atom *irMTypeRef
+		if atom, pos, err = me.parseTypeAtom(toks, pos); err != nil {
+			return nil, pos, err
+		} else if t == nil {
+			t = atom
+		} else {
+			t = &irMTypeRef{TypeApp: &irMTypeRefAppl{Left: t, Right: atom}}
+		}
+	}
+	if t == nil {
+		err = me.errorf("missing type")
+	}
+	return t, pos, err
+}
+
+func (me *facadeDecl) parseTypeAtom(toks []string, pos int) (t *irMTypeRef, _ int, err error) {
+	if pos >= len(toks) {
+		return nil, pos, me.errorf("missing type")
+	}
+	tok := toks[pos]
+	switch i := strings.LastIndex(tok, "."); {
+	case tok == "(":
+		if t, pos, err = me.parseType(toks, pos+1); err == nil {
+			if pos >= len(toks) {
+				err = me.errorf("missing `)`")
+			}
+		}
+	case !ustr.BeginsUpper(tok[i+1:]):
+		err = me.errorf("not a type constructor: %s", tok)
+	case i < 0 && facadePrimTypes[tok]:
+		t = &irMTypeRef{TypeConstructor: "Prim." + tok}
+	case i < 0:
+		t = &irMTypeRef{TypeConstructor: tok} // to be qualified by resolveTypeArgs, once the module's irMeta is at hand
+	default:
+		t = &irMTypeRef{TypeConstructor: tok}
+	}
+	return t, pos + 1, err
+}
+
+// resolveTypeArgs qualifies the non-Prim unqualified type names in our typeArgs: with the module's own if it defines such a type, else with that of the one import exporting it
+func (me *facadeDecl) resolveTypeArgs(mod *modPkg) (err error) {
+	var resolve func(*irMTypeRef)
+	resolve = func(t *irMTypeRef) {
+		if t == nil || err != nil {
+			return
+		} else if t.TypeApp != nil {
+			resolve(t.TypeApp.Left)
+			resolve(t.TypeApp.Right)
+		} else if t.TypeConstructor != "" && !strings.Contains(t.TypeConstructor, ".") {
+			var modqnames []string
+			if mod.irMeta.definesType(t.TypeConstructor) { // our own shadow any imported ones
+				modqnames = append(modqnames, mod.qName)
+			} else {
+				for _, imp := range mod.irMeta.Imports {
+					if impmod := mod.findModuleByQName(imp.PsModQName); impmod != nil && impmod.irMeta.definesType(t.TypeConstructor) && impmod.irMeta.hasExport(t.TypeConstructor) {
+						modqnames = append(modqnames, impmod.qName)
+					}
+				}
+			}
+			if len(modqnames) == 0 {
+				err = me.errorf("unknown type %s: neither of Prim nor defined in %s or exported by any of its imports", t.TypeConstructor, mod.qName)
+			} else if len(modqnames) > 1 {
+				err = me.errorf("ambiguous type %s: exported by each of %s", t.TypeConstructor, strings.Join(modqnames, ", "))
+			} else {
+				t.TypeConstructor = modqnames[0] + "." + t.TypeConstructor
+			}
+		}
+	}
+	for _, typearg := range me.typeArgs {
+		resolve(typearg)
+	}
+	return
+}
+
+var facadePrimTypes = map[string]bool{"Array": true, "Boolean": true, "Char": true, "Function": true, "Int": true, "Number": true, "Record": true, "String": true}
+
+// facadeGoName turns a PS value name into an exported Go identifier, just upper-casing it (and spelling out any primes)
+func facadeGoName(psname string) string {
+	return ustr.Upper.Ensure(strings.Replace(strings.TrimLeft(psname, "_"), "'", "Prime", -1), 0)
+}
+
+func facadeIsExportedIdent(name string) bool {
+	return ustr.BeginsUpper(name) && strings.IndexFunc(name, func(r rune) bool { return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) }) < 0
+}
+
+// checkFacadeMods confirms that all Gonad.Out.Facades entries name known modules
+func (me *session) checkFacadeMods() error {
+	for _, decl := range me.facades {
+		if me.modPkgByQName(decl.modQName) == nil {
+			return decl.errorf("no such module: %s", decl.modQName)
+		}
+	}
+	return nil
+}
+
+// writeFacades (re)writes the facade packages of all Gonad.Out.Facades entries (if any), which concern only the main project: but those of failed modules are left as they were, and so is any whose contents didn't change. Bad entries fail their module, see checkFacadeMods for unknown ones
+func (me *psBowerProject) writeFacades() {
+	if me != &me.sess.proj || len(me.sess.facades) == 0 {
+		return
+	}
+	var mods []*modPkg
+	bymod := map[*modPkg][]*facadeDecl{}
+	for _, decl := range me.sess.facades {
+		mod := me.sess.findModuleByQName(decl.modQName) // never nil, see checkFacadeMods
+		if bymod[mod] == nil {
+			mods = append(mods, mod)
+		}
+		bymod[mod] = append(bymod[mod], decl)
+	}
+	for _, mod := range mods {
+		if !mod.failed() {
+			var err error
+			for _, decl := range bymod[mod] {
+				if err = decl.resolveTypeArgs(mod); err != nil {
+					break
+				}
+			}
+			var src []byte
+			gen := newFacadeGen(mod)
+			if err == nil {
+				src, err = gen.file(bymod[mod])
+			}
+			if err != nil {
+				mod.diags = append(mod.diags, newDiagnostic(mod.qName, err))
+			} else if srcfilepath := gen.filePath(); !facadeFileIs(srcfilepath, src) {
+				if err = ufs.EnsureDirExists(filepath.Dir(srcfilepath)); err == nil {
+					err = ufs.WriteBinaryFile(srcfilepath, src)
+				}
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+}
+
+func facadeFileIs(filepath string, src []byte) bool {
+	cur, err := ioutil.ReadFile(filepath)
+	return err == nil && bytes.Equal(cur, src)
+}
+
+// facadeGen renders the facade package of one module
+type facadeGen struct {
+	mod   *modPkg
+	irast *irAst                 // a scratch one (not mod's), so that codeGenTypeRefDetached qualifies even mod's own types with its package name
+	imps  map[string]bool        // import paths used so far
+	subst map[string]*irMTypeRef // type-var instantiations of the current facadeDecl
+}
+
+func newFacadeGen(mod *modPkg) (me *facadeGen) {
+	me = &facadeGen{mod: mod, imps: map[string]bool{}}
+	scratchmod := &modPkg{proj: mod.proj, irMeta: &irMeta{proj: mod.proj}}
+	scratchmod.irMeta.mod = scratchmod
+	me.irast = &irAst{mod: scratchmod, irM: scratchmod.irMeta}
+	return
+}
+
+func (me *facadeGen) pkgName() (pkgname string) {
+	if pkgname = strings.ToLower(me.mod.lName); token.Lookup(pkgname).IsKeyword() {
+		pkgname += "_"
+	}
+	return
+}
+
+func (me *facadeGen) filePath() string {
+	sess := me.mod.proj.sess
+	return filepath.Join(sess.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, sess.proj.GoOut.PkgDirPath, dirNameFacades, strings.ToLower(me.mod.goOutDirPath), me.pkgName()+".go")
+}
+
+// file renders the whole facade package source, gofmt'd: or the error of the first bad entry among decls, which writeFunc and its helpers panic with (as a *facadeDeclError)
+func (me *facadeGen) file(decls []*facadeDecl) (src []byte, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if declerr, ok := recovered.(*facadeDeclError); ok {
+				src, err = nil, declerr
+			} else {
+				panic(recovered)
+			}
+		}
+	}()
+	var body, buf bytes.Buffer
+	funcs := map[string]*facadeDecl{}
+	for _, decl := range decls {
+		if dupl := funcs[decl.goName]; dupl != nil {
+			return nil, decl.errorf("Go name %s already taken by %q", decl.goName, dupl.entry)
+		}
+		funcs[decl.goName] = decl
+		me.writeFunc(&body, decl)
+	}
+
+	sess, pkgname := me.mod.proj.sess, me.pkgName()
+	if !sess.flag.NoPrefix {
+		fmt.Fprintf(&buf, "// Generated by gonad from: %s, as configured in: %s\n\n", me.mod.irMetaFilePath, sess.proj.BowerJsonFilePath)
+	}
+	fmt.Fprintf(&buf, "// Package %s is the Go facade of PureScript module %s, as generated into package %s.\npackage %s\n\n", pkgname, me.mod.qName, me.mod.impPath(), pkgname)
+	var stdimps, imps []string
+	for imp := range me.imps {
+		if strings.Contains(imp, "/") {
+			imps = append(imps, imp)
+		} else {
+			stdimps = append(stdimps, imp)
+		}
+	}
+	sort.Strings(stdimps)
+	sort.Strings(imps)
+	if len(stdimps) > 0 && len(imps) > 0 {
+		stdimps = append(stdimps, "") // std-lib imports go first, in a group of their own
+	}
+	if allimps := append(stdimps, imps...); len(allimps) > 0 {
+		buf.WriteString("import (\n")
+		for _, imp := range allimps {
+			if imp == "" {
+				buf.WriteString("\n")
+			} else {
+				fmt.Fprintf(&buf, "\t%q\n", imp)
+			}
+		}
+		buf.WriteString(")\n")
+	}
+	buf.Write(body.Bytes())
+	if src, err = format.Source(buf.Bytes()); err != nil {
+		panic(err) // a bug in facadeGen, not a bad entry
+	}
+	return
+}
+
+// writeFunc renders the facade func for decl: uncurried, dictionaries pre-applied, args and results converted as per toGen and fromGen
+func (me *facadeGen) writeFunc(w *bytes.Buffer, decl *facadeDecl) {
+	irM := me.mod.irMeta
+	var evd *irMNamedTypeRef
+	for _, vd := range irM.EnvValDecls {
+		if vd.Name == decl.psName {
+			evd = vd
+			break
+		}
+	}
+	gvd := irM.goValDeclByPsName(decl.psName)
+	if evd == nil || gvd == nil || !irM.hasExport(decl.psName) {
+		panic(decl.errorf("no such export in module %s: %s", me.mod.qName, decl.psName))
+	} else if tcm := irM.tcMember(decl.psName); tcm != nil {
+		gvd = irM.tcMemberSig(tcm, gvd)
+	}
+
+	//	peel off the foralls and constraints, then all args: the latter remaining in each of fnnodes, the former in constrnodes
+	var tvars []string
+	var constrnodes, fnnodes irMTypeRefs
+	t := evd.Ref
+	for t.ForAll != nil || t.ConstrainedType != nil {
+		if t.ForAll != nil {
+			tvars, t = append(tvars, t.ForAll.Name), t.ForAll.Ref
+		} else {
+			constrnodes, t = append(constrnodes, t), t.ConstrainedType.Ref
+		}
+	}
+	for arg, ret := t.psFuncArgAndRet(); arg != nil; arg, ret = t.psFuncArgAndRet() {
+		fnnodes, t = append(fnnodes, t), ret
+	}
+	if len(decl.typeArgs) > len(tvars) {
+		panic(decl.errorf("%d type args for the %d type variables of %s", len(decl.typeArgs), len(tvars), evd.Ref.psString()))
+	}
+	me.subst = map[string]*irMTypeRef{}
+	var insts []string
+	for i, typearg := range decl.typeArgs {
+		me.subst[tvars[i]], insts = typearg, append(insts, tvars[i]+" = "+typearg.psString())
+	}
+
+	//	the call of the generated func, dictionaries first
+	call, calltype := me.pkgSym(me.mod, gvd.NameGo), gvd
+	for _, constrnode := range constrnodes {
+		call, calltype = me.apply(decl, call, calltype, constrnode, me.dict(decl, psTypeSubstConstr(constrnode.ConstrainedType, me.subst), 0))
+	}
+	var params []string
+	for i, fnnode := range fnnodes {
+		argt, _ := fnnode.psFuncArgAndRet()
+		param := string('a' + rune(i))
+		if i >= 8 {
+			param = fmt.Sprintf("a%d", i)
+		}
+		params = append(params, param+" "+me.typeOf(argt))
+		call, calltype = me.apply(decl, call, calltype, fnnode, me.toGen(argt, param))
+	}
+
+	fmt.Fprintf(w, "\n// %s calls %s.%s :: %s", decl.goName, me.mod.qName, decl.psName, evd.Ref.psString())
+	if len(insts) > 0 {
+		fmt.Fprintf(w, "\n// with %s", strings.Join(insts, ", "))
+	}
+	fmt.Fprintf(w, "\nfunc %s(%s) ", decl.goName, strings.Join(params, ", "))
+	calliface, ret := !calltype.hasTypeInfoBeyondEmptyIface(), "ret"
+	if !me.isIface(calltype) {
+		ret = "interface{}(ret)" // for the type assertions below
+	}
+	if val := me.maybeOf(t); val != nil {
+		just, justfield := me.ctor("Data.Maybe", "Maybe", "Just")
+		fmt.Fprintf(w, "(%s, bool) {\nret := %s\nif just, ok := %s.(%s); ok {\nreturn %s, true\n}\nvar zero %s\nreturn zero, false\n}\n",
+			me.typeOf(val), call, ret, just, me.fromGen(val, "just."+justfield.NameGo, !justfield.hasTypeInfoBeyondEmptyIface()), me.typeOf(val))
+	} else if left, right := me.eitherOf(t); left != nil {
+		lefttype, leftfield := me.ctor("Data.Either", "Either", "Left")
+		righttype, rightfield := me.ctor("Data.Either", "Either", "Right")
+		err := me.fromGen(left, "left."+leftfield.NameGo, !leftfield.hasTypeInfoBeyondEmptyIface())
+		if leftps, _ := me.resolve(left); leftps.TypeConstructor == "Prim.String" {
+			me.imps["errors"], err = true, "errors.New("+err+")"
+		} else {
+			me.imps["fmt"], err = true, "fmt.Errorf(\"%v\", "+err+")"
+		}
+		fmt.Fprintf(w, "(%s, error) {\nret := %s\nif left, ok := %s.(%s); ok {\nvar zero %s\nreturn zero, %s\n}\nreturn %s, nil\n}\n",
+			me.typeOf(right), call, ret, lefttype, me.typeOf(right), err,
+			me.fromGen(right, ret+".("+righttype+")."+rightfield.NameGo, !rightfield.hasTypeInfoBeyondEmptyIface()))
+	} else {
+		fmt.Fprintf(w, "%s {\nreturn %s\n}\n", me.typeOf(t), me.fromGen(t, call, calliface))
+	}
+}
+
+// apply renders the call of fn (of Go type fntype, of PS type fnps) with arg. Where fntype isn't known to be a func, fn first gets asserted to the func type that gonad derives from fnps
+func (me *facadeGen) apply(decl *facadeDecl, fn string, fntype *irANamedTypeRef, fnps *irMTypeRef, arg string) (string, *irANamedTypeRef) {
+	if fntype.RefFunc == nil && !fntype.hasTypeInfoBeyondEmptyIface() {
+		fntype = &irANamedTypeRef{}
+		if fntype.setRefFrom(me.mod.irMeta.toIrATypeRef(map[string][]string{}, fnps)); fntype.RefFunc != nil {
+			fn += ".(" + me.genTypeRef(fntype) + ")"
+		}
+	}
+	if fntype.RefFunc == nil || len(fntype.RefFunc.Args) != 1 || len(fntype.RefFunc.Rets) != 1 {
+		panic(decl.errorf("not callable as per its Go type: %s", fn))
+	}
+	return fn + "(" + arg + ")", fntype.RefFunc.Rets[0]
+}
+
+// dict renders the instance dictionary for constr, whose args must be fully instantiated: found among the instances in the modules of the class and of the args' type constructors, as per PureScript's rule against orphan instances
+func (me *facadeGen) dict(decl *facadeDecl, constr *irMTypeRefConstr, depth int) string {
+	if depth > 16 {
+		panic(decl.errorf("instance resolution too deep for %s", constr.psString()))
+	}
+	modqnames := []string{constr.Class[:strings.LastIndex(constr.Class, ".")]}
+	for _, arg := range constr.Args {
+		if !arg.psTypeWalk(func(t *irMTypeRef) bool {
+			if i := strings.LastIndex(t.TypeConstructor, "."); i > 0 {
+				modqnames = append(modqnames, t.TypeConstructor[:i])
+			}
+			return t.TypeVar == "" && t.Skolem == nil
+		}) {
+			panic(decl.errorf("type variables in constraint %s: instantiate them via @Type", constr.psString()))
+		}
+	}
+	for _, modqname := range modqnames {
+		mod := me.mod.findModuleByQName(modqname)
+		if mod == nil {
+			continue
+		}
+		for _, tci := range mod.irMeta.EnvTypeClassInsts {
+			if tci.ClassName != constr.Class || len(tci.InstTypes) != len(constr.Args) {
+				continue
+			}
+			binds := map[string]*irMTypeRef{}
+			matches := true
+			for i, insttype := range tci.InstTypes {
+				matches = matches && insttype.psTypeMatch(constr.Args[i], binds)
+			}
+			if !matches {
+				continue
+			}
+			gvd := mod.irMeta.goValDeclByPsName(tci.Name)
+			if gvd == nil {
+				panic(decl.errorf("no Go declaration for instance %s.%s", mod.qName, tci.Name))
+			}
+			dict, dicttype := me.pkgSym(mod, gvd.NameGo), gvd
+			for _, evd := range mod.irMeta.EnvValDecls { // the instance's own constraints, if any
+				if evd.Name == tci.Name {
+					for t := evd.Ref; t.ForAll != nil || t.ConstrainedType != nil; {
+						if t.ForAll != nil {
+							t = t.ForAll.Ref
+						} else {
+							dict, dicttype = me.apply(decl, dict, dicttype, t, me.dict(decl, psTypeSubstConstr(t.ConstrainedType, binds), depth+1))
+							t = t.ConstrainedType.Ref
+						}
+					}
+					break
+				}
+			}
+			return dict
+		}
+	}
+	panic(decl.errorf("no instance found for %s", constr.psString()))
+}
+
+// ctor returns the Go struct type (qualified, and a pointer if passed by one) of a data ctor, along with its first field
+func (me *facadeGen) ctor(modqname string, tname string, ctorname string) (gotype string, field0 *irANamedTypeRef) {
+	if mod := me.mod.findModuleByQName(modqname); mod != nil {
+		if gtd := mod.irMeta.goTypeDefByGoName(sanitizeSymbolForGo(tname+"۰"+ctorname, true)); gtd != nil && gtd.RefStruct != nil {
+			if gotype = me.pkgSym(mod, gtd.NameGo); gtd.RefStruct.PassByPtr {
+				gotype = "*" + gotype
+			}
+			if len(gtd.RefStruct.Fields) > 0 {
+				field0 = gtd.RefStruct.Fields[0]
+			}
+			return
+		}
+	}
+	return "", nil
+}
+
+// maybeOf returns the a of t if it's a Maybe a (and Data.Maybe is around as expected)
+func (me *facadeGen) maybeOf(t *irMTypeRef) *irMTypeRef {
+	if t, _ = me.resolve(t); t.TypeApp != nil && t.TypeApp.Left.TypeConstructor == "Data.Maybe.Maybe" {
+		if just, field := me.ctor("Data.Maybe", "Maybe", "Just"); field != nil {
+			if nothing, _ := me.ctor("Data.Maybe", "Maybe", "Nothing"); just != "" && nothing != "" {
+				return t.TypeApp.Right
+			}
+		}
+	}
+	return nil
+}
+
+// eitherOf returns the e and a of t if it's an Either e a (and Data.Either is around as expected)
+func (me *facadeGen) eitherOf(t *irMTypeRef) (left *irMTypeRef, right *irMTypeRef) {
+	if t, _ = me.resolve(t); t.TypeApp != nil && t.TypeApp.Left.TypeApp != nil && t.TypeApp.Left.TypeApp.Left.TypeConstructor == "Data.Either.Either" {
+		if _, leftfield := me.ctor("Data.Either", "Either", "Left"); leftfield != nil {
+			if _, rightfield := me.ctor("Data.Either", "Either", "Right"); rightfield != nil {
+				return t.TypeApp.Left.TypeApp.Right, t.TypeApp.Right
+			}
+		}
+	}
+	return nil, nil
+}
+
+// resolve returns the instantiation of t if it's an instantiated type var
+func (me *facadeGen) resolve(t *irMTypeRef) (_ *irMTypeRef, isvar bool) {
+	if t.TypeVar != "" && me.subst[t.TypeVar] != nil {
+		return me.subst[t.TypeVar], true
+	}
+	return t, false
+}
+
+// typeOf is the Go type that facade funcs use for values of PS type t
+func (me *facadeGen) typeOf(t *irMTypeRef) string {
+	if tinst, isvar := me.resolve(t); isvar {
+		return me.typeOf(tinst)
+	} else if t.TypeVar != "" {
+		return "interface{}"
+	}
+	switch t.TypeConstructor {
+	case "Prim.Int":
+		return "int"
+	case "Prim.String":
+		return "string"
+	case "Prim.Char":
+		return "rune"
+	}
+	if elem := t.psArrayOf(); elem != nil {
+		return "[]" + me.typeOf(elem)
+	} else if val := me.maybeOf(t); val != nil {
+		return "*" + me.typeOf(val)
+	}
+	return me.genTypeOf(t)
+}
+
+// genTypeOf is the Go type of the generated code for values of PS type t, as per irMeta.toIrATypeRef
+func (me *facadeGen) genTypeOf(t *irMTypeRef) string {
+	if t.TypeVar != "" {
+		return "interface{}"
+	} else if elem := t.psArrayOf(); elem != nil {
+		return "[]" + me.genTypeOf(elem)
+	}
+	tref := &irANamedTypeRef{}
+	tref.setRefFrom(me.mod.irMeta.toIrATypeRef(map[string][]string{}, t))
+	return me.genTypeRef(tref)
+}
+
+func (me *facadeGen) genTypeRef(tref *irANamedTypeRef) string {
+	gotype, imps := me.irast.codeGenTypeRefDetached(tref)
+	for _, imp := range imps {
+		me.imps[imp.ImpPath] = true
+	}
+	return gotype
+}
+
+// toGen renders the conversion of x (of Go type typeOf(t)) to the generated code's genTypeOf(t) representation
+func (me *facadeGen) toGen(t *irMTypeRef, x string) string {
+	if tinst, isvar := me.resolve(t); isvar {
+		return me.toGen(tinst, x)
+	}
+	utf16 := me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16
+	switch {
+	case t.TypeConstructor == "Prim.Int":
+		return "int32(" + x + ")" // wraps around beyond int32's range, as documented atop
+
+	case t.TypeConstructor == "Prim.String" && utf16:
+		return me.gonadz() + ".S(" + x + ")"
+	case t.TypeConstructor == "Prim.Char" && utf16:
+		return me.gonadz() + ".Char(" + x + ")"
+	}
+	if elem := t.psArrayOf(); elem != nil && me.typeOf(elem) != me.genTypeOf(elem) {
+		return fmt.Sprintf("func(xs []%s) []%s {\nys := make([]%s, len(xs))\nfor i, x := range xs {\nys[i] = %s\n}\nreturn ys\n}(%s)",
+			me.typeOf(elem), me.genTypeOf(elem), me.genTypeOf(elem), me.toGen(elem, "x"), x)
+	} else if val := me.maybeOf(t); val != nil {
+		just, justfield := me.ctor("Data.Maybe", "Maybe", "Just")
+		nothing, _ := me.ctor("Data.Maybe", "Maybe", "Nothing")
+		return fmt.Sprintf("func(p *%s) %s {\nif p == nil {\nreturn %s\n}\nreturn %s\n}(%s)",
+			me.typeOf(val), me.genTypeOf(t), facadeStructLit(nothing, ""), facadeStructLit(just, justfield.NameGo+": "+me.toGen(val, "*p")), x)
+	}
+	return x
+}
+
+// fromGen renders the conversion of x (of Go type genTypeOf(t), or empty-interface if xiface) to typeOf(t)
+func (me *facadeGen) fromGen(t *irMTypeRef, x string, xiface bool) string {
+	if tinst, isvar := me.resolve(t); isvar {
+		return me.fromGen(tinst, x, true) // type vars are empty-interface in generated code
+	} else if t.TypeVar != "" {
+		return x
+	} else if gotype := me.genTypeOf(t); xiface && gotype != "interface{}" {
+		x += ".(" + gotype + ")"
+	}
+	utf16 := me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16
+	switch {
+	case t.TypeConstructor == "Prim.Int":
+		return "int(" + x + ")"
+	case t.TypeConstructor == "Prim.String" && utf16:
+		return me.gonadz() + ".GoStr(" + x + ")"
+	case t.TypeConstructor == "Prim.Char" && utf16:
+		return "rune(" + x + ")"
+	}
+	if elem := t.psArrayOf(); elem != nil && me.typeOf(elem) != me.genTypeOf(elem) {
+		return fmt.Sprintf("func(ys []%s) []%s {\nxs := make([]%s, len(ys))\nfor i, y := range ys {\nxs[i] = %s\n}\nreturn xs\n}(%s)",
+			me.genTypeOf(elem), me.typeOf(elem), me.typeOf(elem), me.fromGen(elem, "y", false), x)
+	} else if val := me.maybeOf(t); val != nil {
+		just, justfield := me.ctor("Data.Maybe", "Maybe", "Just")
+		return fmt.Sprintf("func(m %s) *%s {\nif just, ok := m.(%s); ok {\nx := %s\nreturn &x\n}\nreturn nil\n}(%s)",
+			me.genTypeOf(t), me.typeOf(val), just, me.fromGen(val, "just."+justfield.NameGo, !justfield.hasTypeInfoBeyondEmptyIface()), x)
+	}
+	return x
+}
+
+// isIface reports whether tref is an interface type (such as that of a data type), whose values can be type-asserted
+func (me *facadeGen) isIface(tref *irANamedTypeRef) bool {
+	if tref.RefInterface != nil || !tref.hasTypeInfoBeyondEmptyIface() {
+		return true
+	} else if i := strings.LastIndex(tref.RefAlias, "."); i > 0 {
+		if mod := me.mod.findModuleByQName(tref.RefAlias[:i]); mod != nil {
+			if gtd := mod.irMeta.goTypeDefByPsName(tref.RefAlias[i+1:]); gtd != nil {
+				return gtd.RefInterface != nil
+			}
+		}
+	}
+	return false
+}
+
+func (me *facadeGen) gonadz() string {
+	me.imps[impPathDefaultFfiRoot] = true
+	return "𝒈"
+}
+
+func (me *facadeGen) pkgSym(mod *modPkg, name string) string {
+	me.imps[mod.impPath()] = true
+	return mod.pName + "." + name
+}
+
+// facadeStructLit renders a composite literal of gotype, which is a ctor struct type as returned by facadeGen.ctor
+func facadeStructLit(gotype string, fields string) string {
+	if strings.HasPrefix(gotype, "*") {
+		return "&" + gotype[1:] + "{" + fields + "}"
+	}
+	return gotype + "{" + fields + "}"
+}
+
+// psFuncArgAndRet returns a and b if we're a `a -> b`
+func (me *irMTypeRef) psFuncArgAndRet() (arg *irMTypeRef, ret *irMTypeRef) {
+	if me.TypeApp != nil && me.TypeApp.Left.TypeApp != nil && me.TypeApp.Left.TypeApp.Left.TypeConstructor == "Prim.Function" {
+		arg, ret = me.TypeApp.Left.TypeApp.Right, me.TypeApp.Right
+	}
+	return
+}
+
+// psArrayOf returns a if we're an `Array a`
+func (me *irMTypeRef) psArrayOf() *irMTypeRef {
+	if me.TypeApp != nil && me.TypeApp.Left.TypeConstructor == "Prim.Array" {
+		return me.TypeApp.Right
+	}
+	return nil
+}
+
+// psTypeWalk calls on for us and all our sub-types, depth-first, for as long as on returns true
+func (me *irMTypeRef) psTypeWalk(on func(*irMTypeRef) bool) bool {
+	if me == nil {
+		return true
+	} else if !on(me) {
+		return false
+	}
+	switch {
+	case me.TypeApp != nil:
+		return me.TypeApp.Left.psTypeWalk(on) && me.TypeApp.Right.psTypeWalk(on)
+	case me.RCons != nil:
+		return me.RCons.Left.psTypeWalk(on) && me.RCons.Right.psTypeWalk(on)
+	case me.ForAll != nil:
+		return me.ForAll.Ref.psTypeWalk(on)
+	case me.ConstrainedType != nil:
+		for _, arg := range me.ConstrainedType.Args {
+			if !arg.psTypeWalk(on) {
+				return false
+			}
+		}
+		return me.ConstrainedType.Ref.psTypeWalk(on)
+	}
+	return true
+}
+
+// psTypeMatch reports whether concrete is an instance of us, binding our type vars accordingly
+func (me *irMTypeRef) psTypeMatch(concrete *irMTypeRef, binds map[string]*irMTypeRef) bool {
+	switch {
+	case me == nil || concrete == nil:
+		return me == concrete
+	case me.TypeVar != "":
+		if bound := binds[me.TypeVar]; bound != nil {
+			return bound.eq(concrete)
+		}
+		binds[me.TypeVar] = concrete
+		return true
+	case me.TypeApp != nil:
+		return concrete.TypeApp != nil && me.TypeApp.Left.psTypeMatch(concrete.TypeApp.Left, binds) && me.TypeApp.Right.psTypeMatch(concrete.TypeApp.Right, binds)
+	case me.RCons != nil:
+		return concrete.RCons != nil && me.RCons.Label == concrete.RCons.Label && me.RCons.Left.psTypeMatch(concrete.RCons.Left, binds) && me.RCons.Right.psTypeMatch(concrete.RCons.Right, binds)
+	}
+	return me.eq(concrete)
+}
+
+// psTypeSubst returns a copy of t with the type vars in subst replaced (except where shadowed by a forall)
+func psTypeSubst(t *irMTypeRef, subst map[string]*irMTypeRef) *irMTypeRef {
+	if t == nil || len(subst) == 0 {
+		return t
+	} else if t.TypeVar != "" && subst[t.TypeVar] != nil {
+		return subst[t.TypeVar]
+	}
+	copy := *t
+	switch {
+	case t.TypeApp != nil:
+		copy.TypeApp = &irMTypeRefAppl{Left: psTypeSubst(t.TypeApp.Left, subst), Right: psTypeSubst(t.TypeApp.Right, subst)}
+	case t.RCons != nil:
+		copy.RCons = &irMTypeRefRow{Label: t.RCons.Label, Left: psTypeSubst(t.RCons.Left, subst), Right: psTypeSubst(t.RCons.Right, subst)}
+	case t.ConstrainedType != nil:
+		copy.ConstrainedType = psTypeSubstConstr(t.ConstrainedType, subst)
+	case t.ForAll != nil:
+		if subst[t.ForAll.Name] != nil {
+			shadowed := make(map[string]*irMTypeRef, len(subst))
+			for tvar, tinst := range subst {
+				if tvar != t.ForAll.Name {
+					shadowed[tvar] = tinst
+				}
+			}
+			subst = shadowed
+		}
+		forall := *t.ForAll
+		forall.Ref, copy.ForAll = psTypeSubst(t.ForAll.Ref, subst), &forall
+	}
+	return &copy
+}
+
+func psTypeSubstConstr(constr *irMTypeRefConstr, subst map[string]*irMTypeRef) *irMTypeRefConstr {
+	copy := &irMTypeRefConstr{Class: constr.Class, Ref: psTypeSubst(constr.Ref, subst)}
+	for _, arg := range constr.Args {
+		copy.Args = append(copy.Args, psTypeSubst(arg, subst))
+	}
+	return copy
+}
@@ -0,0 +1,157 @@
+package gonad
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+/*
+Facades of exports using Maybe, Either and Array, which the
+testdata/golden scenarios can't cover (having no Prelude):
+over an in-memory Data.Maybe, Data.Either and My.Mod, just
+the signatures are checked, along with the file parsing.
+*/
+
+func facadeTestSession() (sess *session, mod *modPkg) {
+	tc := func(name string) *irMTypeRef { return &irMTypeRef{TypeConstructor: name} }
+	tv := func(name string) *irMTypeRef { return &irMTypeRef{TypeVar: name} }
+	app := func(left *irMTypeRef, right *irMTypeRef) *irMTypeRef {
+		return &irMTypeRef{TypeApp: &irMTypeRefAppl{Left: left, Right: right}}
+	}
+	fn := func(arg *irMTypeRef, ret *irMTypeRef) *irMTypeRef { return app(app(tc("Prim.Function"), arg), ret) }
+
+	sess = newSession(Options{})
+	sess.deps[""], sess.proj.GoOut.PkgDirPath = &sess.proj, "test"
+	sess.proj.BowerJsonFile.Gonad.CodeGen.PtrStructMinFieldCount = 2
+	newmod := func(qname string, exports ...string) *modPkg {
+		m := &modPkg{qName: qname, pName: strReplDot2ꓸ.Replace(qname), lName: qname[strings.LastIndex(qname, ".")+1:], goOutDirPath: strReplDot2Slash.Replace(qname), proj: &sess.proj}
+		m.irMeta = &irMeta{mod: m, proj: &sess.proj, Exports: exports}
+		sess.proj.Modules = append(sess.proj.Modules, m)
+		return m
+	}
+	maybe := newmod("Data.Maybe", "Maybe", "MaybeĸNothing", "MaybeĸJust")
+	maybe.irMeta.EnvTypeDataDecls = []*irMTypeDataDecl{{Name: "Maybe", Args: []string{"a"}, Ctors: []*irMTypeDataCtor{{Name: "Nothing"}, {Name: "Just", Args: irMTypeRefs{tv("a")}}}}}
+	either := newmod("Data.Either", "Either", "EitherĸLeft", "EitherĸRight")
+	either.irMeta.EnvTypeDataDecls = []*irMTypeDataDecl{{Name: "Either", Args: []string{"a", "b"}, Ctors: []*irMTypeDataCtor{{Name: "Left", Args: irMTypeRefs{tv("a")}}, {Name: "Right", Args: irMTypeRefs{tv("b")}}}}}
+	mod = newmod("My.Mod", "lookup", "parse", "firsts")
+	mod.irMeta.EnvValDecls = []*irMNamedTypeRef{
+		{Name: "lookup", Ref: fn(tc("Prim.String"), fn(app(tc("Prim.Array"), tc("Prim.Int")), app(tc("Data.Maybe.Maybe"), tc("Prim.Int"))))},
+		{Name: "parse", Ref: fn(tc("Prim.String"), app(app(tc("Data.Either.Either"), tc("Prim.String")), tc("Prim.Int")))},
+		{Name: "firsts", Ref: &irMTypeRef{ForAll: &irMTypeRefExist{Name: "a", Ref: fn(app(tc("Prim.Array"), app(tc("Data.Maybe.Maybe"), tv("a"))), app(tc("Prim.Array"), tv("a")))}}},
+	}
+	for _, m := range sess.proj.Modules {
+		m.irMeta.GoTypeDefs = m.irMeta.toIrADataTypeDefs(m.irMeta.EnvTypeDataDecls)
+		m.irMeta.idxInvalidate()
+		m.irMeta.populateGoValDecls()
+	}
+	sess.indexModPkgs()
+	return
+}
+
+func TestFacadeSigs(t *testing.T) {
+	_, mod := facadeTestSession()
+	decls, err := parseFacadeDecls([]string{"My.Mod.lookup", "My.Mod.parse as ParseInt", "My.Mod.firsts @String"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := newFacadeGen(mod)
+	src, err := gen.file(decls)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = parser.ParseFile(token.NewFileSet(), gen.filePath(), src, 0); err != nil {
+		t.Fatalf("%v in:\n%s", err, src)
+	}
+	for _, sig := range []string{
+		"func Lookup(a string, b []int) (int, bool) {",
+		"func ParseInt(a string) (int, error) {",
+		"func Firsts(a []*string) []string {",
+	} {
+		if !strings.Contains(string(src), sig) {
+			t.Errorf("missing `%s` in:\n%s", sig, src)
+		}
+	}
+	if filepath := gen.filePath(); !strings.HasSuffix(filepath, "my/mod/mod.go") {
+		t.Errorf("unexpected facade file path %s", filepath)
+	}
+}
+
+// bad entries make for errors, not panics: and unknown modules get caught before any facadeGen
+func TestFacadeBadEntries(t *testing.T) {
+	sess, mod := facadeTestSession()
+	for _, bad := range [][]string{
+		{"My.Mod.lookup", "My.Mod.parse as Lookup"},
+		{"My.Mod.nope"},
+		{"My.Mod.firsts @Int @Int"},
+	} {
+		decls, err := parseFacadeDecls(bad)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err = newFacadeGen(mod).file(decls); err == nil {
+			t.Errorf("no error for %q", bad)
+		}
+	}
+	for entry, ok := range map[string]bool{"My.Mod.lookup": true, "No.Such.Mod.lookup": false} {
+		if sess.facades, _ = parseFacadeDecls([]string{entry}); (sess.checkFacadeMods() == nil) != ok {
+			t.Errorf("%s: want ok=%v", entry, ok)
+		}
+	}
+}
+
+func TestFacadeDeclParse(t *testing.T) {
+	decls, err := parseFacadeDecls([]string{"My.Mod.frob'", "My.Mod.frob @Int @(Array (Maybe String)) @T as Frobbed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decl := decls[0]; decl.modQName != "My.Mod" || decl.psName != "frob'" || decl.goName != "FrobPrime" || len(decl.typeArgs) != 0 {
+		t.Errorf("unexpected %#v", decl)
+	}
+	if decl := decls[1]; decl.goName != "Frobbed" || len(decl.typeArgs) != 3 {
+		t.Errorf("unexpected %#v", decl)
+	} else {
+		for i, want := range []string{"Int", "Array (Maybe String)", "T"} { // unqualified until resolveTypeArgs
+			if got := decl.typeArgs[i].psString(); got != want {
+				t.Errorf("type arg #%d: want %s, got %s", i, want, got)
+			}
+		}
+	}
+	for _, bad := range []string{"", "frob", "My.Mod.Frob", "My.Mod.frob @a", "My.Mod.frob @(Array Int", "My.Mod.frob as frob", "My.Mod.frob as", "My.Mod.frob Int"} {
+		if _, err = parseFacadeDecls([]string{bad}); err == nil {
+			t.Errorf("no error for %q", bad)
+		}
+	}
+}
+
+func TestFacadeTypeArgsResolve(t *testing.T) {
+	sess, mod := facadeTestSession()
+	mod.irMeta.Imports = irMPkgRefs{{PsModQName: "Data.Maybe"}, {PsModQName: "Data.Either"}}
+	mod.irMeta.EnvTypeDataDecls = append(mod.irMeta.EnvTypeDataDecls, &irMTypeDataDecl{Name: "Either"})
+	mod.irMeta.idxInvalidate()
+	for entry, want := range map[string]string{
+		"My.Mod.firsts @(Maybe Int)":               "Data.Maybe.Maybe Int",
+		"My.Mod.firsts @(Array (Maybe String))":    "Array (Data.Maybe.Maybe String)",
+		"My.Mod.firsts @(Either Data.Maybe.Maybe)": "My.Mod.Either Data.Maybe.Maybe", // our own shadow imported ones
+	} {
+		if decls, err := parseFacadeDecls([]string{entry}); err != nil {
+			t.Fatal(err)
+		} else if err = decls[0].resolveTypeArgs(mod); err != nil {
+			t.Errorf("%s: %v", entry, err)
+		} else if got := decls[0].typeArgs[0].psString(); got != want {
+			t.Errorf("%s: want %s, got %s", entry, want, got)
+		}
+	}
+
+	other := &modPkg{qName: "Other.Maybe", pName: "OtherꓸMaybe", proj: &sess.proj}
+	other.irMeta = &irMeta{mod: other, proj: &sess.proj, Exports: []string{"Maybe"}, EnvTypeDataDecls: []*irMTypeDataDecl{{Name: "Maybe"}}}
+	sess.proj.Modules = append(sess.proj.Modules, other)
+	sess.indexModPkgs()
+	mod.irMeta.Imports = append(mod.irMeta.Imports, &irMPkgRef{PsModQName: other.qName})
+	for _, bad := range []string{"My.Mod.firsts @Nope", "My.Mod.firsts @(Array Maybe)"} { // unknown, ambiguous
+		if decls, err := parseFacadeDecls([]string{bad}); err != nil {
+			t.Fatal(err)
+		} else if err = decls[0].resolveTypeArgs(mod); err == nil {
+			t.Errorf("no error for %q: %s", bad, decls[0].typeArgs[0].psString())
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package gonad
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/metaleap/go-util/dev/go"
+	"github.com/metaleap/go-util/fs"
+	"github.com/metaleap/go-util/slice"
+	"github.com/metaleap/go-util/str"
+)
+
+/*
+Gonad's default FFI packages: Go implementations of
+the foreign imports of the official PureScript core
+libs (prelude, effect, console, arrays, strings etc.)
+
+They're maintained in this repo's gonadz directory, a
+mirror of the github.com/gonadz/g import-path tree
+that generated code refers to (see prefixDefaultFfiPkgImpPath),
+and get copied into Gonad.Out.GoDirSrcPath when missing or outdated.
+(Formerly github.com/gonadz/-, which the go command rejects as an
+invalid directory name since Go 1.13 or so.)
+
+User-supplied FFI: for some src/My/Mod.purs, any src/My/Mod.go
+(and GOOS/GOARCH variants such as src/My/Mod_windows.go, but
+not src/My/Mod_Sub.go which is Mod_Sub.purs's) get copied
+right into the generated package (with their package
+clause fixed up), so foreign values and foreign data types are
+plain package members both for the module and its importers.
+Each foreign import `foo` must be implemented as exported `Foo`
+of the Go type gonad derives from its PureScript signature,
+which is checked via go/types before copying (with stand-ins
+for the types of the not-yet-built generated packages, and a
+warning for any signature still not checkable). To get started,
+`gonad ffi-stubs` writes (or extends) src/My/Mod.go with
+correctly-typed but panicking stubs for all missing ones.
+*/
+
+const (
+	dirNameDefaultFfiPkgs = "gonadz"
+	impPathDefaultFfiRoot = "github.com/gonadz/g"
+)
+
+func defaultFfiPkgsDirPath() string {
+	for _, gopath := range udevgo.AllGoPaths() {
+		if dirpath := filepath.Join(gopath, "src", "github.com", "metaleap", "gonad", dirNameDefaultFfiPkgs); ufs.DirExists(dirpath) {
+			return dirpath
+		}
+	}
+	return ""
+}
+
+func (me *session) ensureDefaultFfiPkgs() (err error) {
+	srcdirpath := me.flag.FfiPkgsPath
+	if srcdirpath == "" {
+		return errors.New("cannot find gonad's default FFI packages (expected in some GOPATH's src/github.com/metaleap/gonad/" + dirNameDefaultFfiPkgs + ", unless given in Options.FfiPkgsPath)")
+	}
+	dstdirpath := filepath.Join(me.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, impPathDefaultFfiRoot)
+	ufs.WalkAllFiles(srcdirpath, func(srcfilepath string) bool {
+		if strings.HasSuffix(srcfilepath, ".go") {
+			dstfilepath := filepath.Join(dstdirpath, srcfilepath[len(srcdirpath):])
+			if isoutdated, _ := ufs.IsNewerThan(srcfilepath, dstfilepath); me.irMetasLoadLazily && !(isoutdated || me.flag.ForceAll) && ufs.FileExists(dstfilepath) {
+				return true // up to date, and deployed for the current StringRepr (else irMetasLoadLazily would be false)
+			}
+			var src []byte
+			if src, err = ioutil.ReadFile(srcfilepath); err == nil {
+				// the StringRepr-specific variants (see gonadz/str.go) get deployed without their build constraint, but only the one matching
+				if strrepr, unconstrained := strReprOfFfiSrc(src); strrepr != "" && strrepr != me.proj.BowerJsonFile.Gonad.CodeGen.StringRepr {
+					if ufs.FileExists(dstfilepath) {
+						err = os.Remove(dstfilepath)
+					}
+				} else if isoutdated, _ := ufs.IsNewerThan(srcfilepath, dstfilepath); isoutdated || me.flag.ForceAll || !ufs.FileExists(dstfilepath) {
+					if err = ufs.EnsureDirExists(filepath.Dir(dstfilepath)); err == nil {
+						err = ufs.WriteBinaryFile(dstfilepath, unconstrained)
+					}
+				}
+			}
+		}
+		return err == nil
+	})
+	return
+}
+
+func strReprOfFfiSrc(src []byte) (strrepr string, unconstrained []byte) {
+	unconstrained = src
+	for tag, repr := range map[string]string{"//go:build gonad_utf16\n\n": strReprUtf16, "//go:build !gonad_utf16\n\n": strReprUtf8} {
+		if bytes.HasPrefix(src, []byte(tag)) {
+			strrepr, unconstrained = repr, src[len(tag):]
+		}
+	}
+	return
+}
+
+// ffiKnownOsArchs are the file-name suffixes (as per go/build) that make some src/My/Mod_xyz.go a variant of src/My/Mod.go
+var ffiKnownOsArchs = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+	"386": true, "amd64": true, "arm": true, "arm64": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "ppc64": true, "ppc64le": true, "riscv64": true, "s390x": true, "wasm": true,
+}
+
+func findFfiGoFiles(pursfilepath string) (ffifilepaths []string) {
+	base := pursfilepath[:len(pursfilepath)-len(".purs")]
+	if ufs.FileExists(base + ".go") {
+		ffifilepaths = append(ffifilepaths, base+".go")
+	}
+	if variants, _ := filepath.Glob(base + "_*.go"); len(variants) > 0 {
+		for _, ffifilepath := range variants {
+			if isffi, suffixes := true, strings.Split(ffifilepath[len(base)+1:len(ffifilepath)-len(".go")], "_"); len(suffixes) <= 2 {
+				for _, suffix := range suffixes {
+					isffi = isffi && ffiKnownOsArchs[suffix]
+				}
+				if isffi {
+					ffifilepaths = append(ffifilepaths, ffifilepath)
+				}
+			}
+		}
+	}
+	return
+}
+
+// ffiGoFileCopies are the `ffi.*.go` files in our generated package's directory, as written by writeFfiGoFiles
+func (me *modPkg) ffiGoFileCopies() (filepaths []string) {
+	filepaths, _ = filepath.Glob(filepath.Join(filepath.Dir(me.gopkgfilepath), "ffi.*.go"))
+	return
+}
+
+// hasStaleFfiGoFiles is true if some user-supplied FFI file is newer than our .go file, or no longer there (but its copy is)
+func (me *modPkg) hasStaleFfiGoFiles() bool {
+	for _, ffifilepath := range me.ffiFilePaths {
+		if staleffi, _ := ufs.IsNewerThan(ffifilepath, me.gopkgfilepath); staleffi {
+			return true
+		}
+	}
+	return len(me.ffiGoFileCopies()) != len(me.ffiFilePaths)
+}
+
+func (me *irMeta) goNameForForeignVal(nameps string) (namego string) {
+	if namego = sanitizeSymbolForGo(nameps, true); namego[0] == '_' {
+		namego = "Ʊ" + namego[1:] // can't upper-case that, but exported from the FFI package it must be (eg. `_insertAt`)
+	}
+	if me.goTypeDefByGoName(namego) != nil || me.typeDataDecl(namego) != nil {
+		namego += "ˆ" // same disambiguation as in populateGoValDecls, eg. Data.Unit's `unit` vs. `Unit`
+	}
+	return
+}
+
+func (me *modPkg) checkFfiGoFiles() (err error) {
+	fset, ctx, ffitypenames := token.NewFileSet(), build.Default, map[string]bool{}
+	var files []*ast.File
+	for _, ffifilepath := range me.ffiFilePaths {
+		if ok, _ := ctx.MatchFile(filepath.Dir(ffifilepath), filepath.Base(ffifilepath)); ok {
+			var file *ast.File
+			if file, err = parser.ParseFile(fset, ffifilepath, nil, 0); err != nil {
+				return
+			}
+			for _, decl := range file.Decls {
+				if gdecl, _ := decl.(*ast.GenDecl); gdecl != nil && gdecl.Tok == token.TYPE {
+					for _, spec := range gdecl.Specs {
+						ffitypenames[spec.(*ast.TypeSpec).Name.Name] = true
+					}
+				}
+			}
+			files = append(files, file)
+		}
+	}
+	//	generated packages (ours included) aren't built yet: stand-ins for their types let signatures referring to those get checked all the same
+	imp := &ffiImporter{Importer: importer.ForCompiler(fset, "source", nil), ffi: newFfiDefaultPkg(), mod: me, pkgs: map[string]*types.Package{}}
+	pkg := types.NewPackage(me.impPath(), "") // named by the files, whose package clauses writeFfiGoFiles fixes up only later
+	imp.declareTypeDefs(pkg, me.irMeta, ffitypenames)
+	//	type errors are expected all the same: the FFI code's own uses of such stand-ins, or of unresolvable imports
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	_ = types.NewChecker(&conf, fset, pkg, nil).Files(files)
+	//	so that types.Eval below (in package scope, unlike the files' imports) knows `𝒈`
+	pkg.Scope().Insert(types.NewPkgName(token.NoPos, pkg, imp.ffi.Name(), imp.ffi))
+	unchecked := func(fvname string, why string) {
+		me.diags = append(me.diags, &Diagnostic{Module: me.qName, Warning: true, Message: fmt.Sprintf("%s: foreign import `%s` not type-checked: %s", me.srcFilePath, fvname, why)})
+	}
+	for _, fvname := range me.irMeta.EnvForeignVals {
+		gvd, fvnamego := me.irMeta.goValDeclByPsName(fvname), me.irMeta.goNameForForeignVal(fvname)
+		obj := pkg.Scope().Lookup(fvnamego)
+		if obj == nil {
+			return fmt.Errorf("%s: foreign import `%s` not implemented as `%s` in %s", me.srcFilePath, fvname, fvnamego, strings.Join(me.ffiFilePaths, ", "))
+		} else if gvd == nil || !gvd.hasTypeInfo() {
+			unchecked(fvname, "no Go type derived from its PureScript signature")
+			continue
+		} else if objtype := types.TypeString(obj.Type(), nil); strings.Contains(objtype, "invalid type") {
+			unchecked(fvname, fmt.Sprintf("`%s` in %s is of type `%s`, referring to something not resolvable here", fvnamego, fset.Position(obj.Pos()), objtype))
+			continue
+		}
+		gotype, imps := me.irAst.codeGenTypeRefDetached(gvd)
+		if err = imp.declarePkgNames(pkg, imps); err != nil {
+			unchecked(fvname, fmt.Sprintf("its Go type `%s` refers to %v", gotype, err))
+			err = nil
+		} else if tv, e := types.Eval(fset, pkg, token.NoPos, gotype); e != nil {
+			unchecked(fvname, fmt.Sprintf("its Go type `%s` is not resolvable here: %v", gotype, e))
+		} else if !types.Identical(obj.Type(), tv.Type) {
+			return fmt.Errorf("%s: foreign import `%s` expected to be of type `%s` but `%s` in %s is of type `%s`", me.srcFilePath, fvname, gotype, fvnamego, fset.Position(obj.Pos()), types.TypeString(obj.Type(), nil))
+		}
+	}
+	return nil
+}
+
+func (me *modPkg) writeFfiGoFiles() (err error) {
+	//	first drop the copies of FFI files since deleted (or no longer considered such, see findFfiGoFiles)
+	for _, copyfilepath := range me.ffiGoFileCopies() {
+		if uslice.StrHas(me.ffiFilePaths, filepath.Join(filepath.Dir(me.srcFilePath), filepath.Base(copyfilepath)[len("ffi."):])) {
+			continue
+		} else if err = os.Remove(copyfilepath); err != nil {
+			return
+		}
+	}
+	for _, ffifilepath := range me.ffiFilePaths {
+		var src []byte
+		var file *ast.File
+		if src, err = ioutil.ReadFile(ffifilepath); err == nil {
+			if file, err = parser.ParseFile(token.NewFileSet(), ffifilepath, src, parser.PackageClauseOnly); err == nil {
+				//	file positions are 1-based offsets as we parsed with a fresh file-set
+				pos, end := int(file.Name.Pos())-1, int(file.Name.End())-1
+				src = append(append(append([]byte{}, src[:pos]...), me.pName...), src[end:]...)
+				//	"ffi." prefix avoids clashes with our generated file while keeping any GOOS/GOARCH file-name suffix intact
+				err = ufs.WriteBinaryFile(filepath.Join(filepath.Dir(me.gopkgfilepath), "ffi."+filepath.Base(ffifilepath)), src)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+type ffiImporter struct {
+	types.Importer
+	ffi  *types.Package
+	mod  *modPkg
+	pkgs map[string]*types.Package // stand-ins by import path, see Import
+}
+
+// newFfiDefaultPkg is a stand-in for github.com/gonadz/g, declaring those of its types that generated signatures refer to (see gonadz/g.go and gonadz/str-utf16.go)
+func newFfiDefaultPkg() *types.Package {
+	pkg := types.NewPackage(impPathDefaultFfiRoot, "𝒈")
+	pkg.Scope().Insert(types.NewTypeName(token.NoPos, pkg, "𝑻", types.NewInterfaceType(nil, nil).Complete()))
+	pkg.Scope().Insert(types.NewTypeName(token.NoPos, pkg, "Char", types.Typ[types.Uint16]))
+	pkg.Scope().Insert(newFfiStandInType(pkg, "Str", types.Typ[types.String]))
+	pkg.MarkComplete()
+	return pkg
+}
+
+// newFfiStandInType declares a defined type: with an empty-struct underlying type unless given, as only its identity matters to signature checks
+func newFfiStandInType(pkg *types.Package, name string, underlying types.Type) *types.TypeName {
+	if underlying == nil {
+		underlying = types.NewStruct(nil, nil)
+	}
+	tname := types.NewTypeName(token.NoPos, pkg, name, nil)
+	types.NewNamed(tname, underlying, nil)
+	return tname
+}
+
+// Import has stand-ins for gonad's default FFI packages and the generated packages of the modules we import, both declaring just their types. Other packages get imported from source if possible
+func (me *ffiImporter) Import(imppath string) (pkg *types.Package, err error) {
+	if imppath == impPathDefaultFfiRoot {
+		return me.ffi, nil
+	} else if pkg = me.pkgs[imppath]; pkg != nil {
+		return
+	}
+	if strings.HasPrefix(imppath, prefixDefaultFfiPkgImpPath) {
+		//	declares the types that generated packages alias for foreign data, see codeGenTypeDef
+		qname := strReplFsSlash2Dot.Replace(imppath[len(prefixDefaultFfiPkgImpPath):])
+		pkg = types.NewPackage(imppath, prefixDefaultFfiPkgNs+strReplDot2ˈ.Replace(qname))
+		if mod := me.mod.findModuleByQName(qname); mod != nil {
+			for _, gtd := range mod.irMeta.GoTypeDefs {
+				if strings.HasPrefix(gtd.RefAlias, prefixDefaultFfiPkgNs) {
+					pkg.Scope().Insert(newFfiStandInType(pkg, gtd.RefAlias[strings.LastIndex(gtd.RefAlias, ".")+1:], nil))
+				}
+			}
+		}
+	} else {
+		for _, modimp := range me.mod.irMeta.Imports {
+			if modimp.ImpPath == imppath && modimp.PsModQName != "" {
+				if mod := me.mod.findModuleByQName(modimp.PsModQName); mod != nil {
+					pkg = types.NewPackage(imppath, mod.pName)
+					me.declareTypeDefs(pkg, mod.irMeta, nil)
+				}
+				break
+			}
+		}
+	}
+	if pkg == nil {
+		if pkg, err = me.Importer.Import(imppath); err != nil {
+			//	unresolvable: an empty stand-in makes its members invalid-typed rather than failing everything
+			pkg, err = types.NewPackage(imppath, filepath.Base(imppath)), nil
+		}
+	}
+	pkg.MarkComplete()
+	me.pkgs[imppath] = pkg
+	return
+}
+
+// declareTypeDefs declares stand-ins for irM's Go type-defs (except those in skip) in pkg, where those for foreign data alias the default FFI package's
+func (me *ffiImporter) declareTypeDefs(pkg *types.Package, irM *irMeta, skip map[string]bool) {
+	for _, gtd := range irM.GoTypeDefs {
+		tname := gtd.NameGo
+		if pkg.Path() != me.mod.impPath() {
+			tname = ustr.Upper.Ensure(tname, 0) // as referred to from other packages, see irAst.pkgSym
+		}
+		if skip[tname] {
+			continue
+		} else if i := strings.LastIndex(gtd.RefAlias, "."); i > 0 && strings.HasPrefix(gtd.RefAlias, prefixDefaultFfiPkgNs) {
+			if ffipkg, _ := me.Import(prefixDefaultFfiPkgImpPath + strReplˈ2Slash.Replace(gtd.RefAlias[len(prefixDefaultFfiPkgNs):i])); ffipkg != nil {
+				if ffitype := ffipkg.Scope().Lookup(gtd.RefAlias[i+1:]); ffitype != nil {
+					pkg.Scope().Insert(types.NewTypeName(token.NoPos, pkg, tname, ffitype.Type()))
+					continue
+				}
+			}
+		}
+		pkg.Scope().Insert(newFfiStandInType(pkg, tname, nil))
+	}
+}
+
+// declarePkgNames makes the packages of imps known in pkg's scope by their package names, as generated code imports them unaliased (see codeGenModImps)
+func (me *ffiImporter) declarePkgNames(pkg *types.Package, imps irMPkgRefs) error {
+	for _, modimp := range imps {
+		imppkg, err := me.Import(modimp.ImpPath)
+		if err != nil {
+			return err
+		}
+		if alt := pkg.Scope().Insert(types.NewPkgName(token.NoPos, pkg, imppkg.Name(), imppkg)); alt != nil {
+			if pkgname, _ := alt.(*types.PkgName); pkgname == nil || pkgname.Imported() != imppkg {
+				return fmt.Errorf("package %s, whose name `%s` the FFI code declares otherwise", modimp.ImpPath, imppkg.Name())
+			}
+		}
+	}
+	return nil
+}
+
+func (me *psBowerProject) writeFfiStubs(deffidirpath string) {
+	me.forAll(func(m *modPkg) {
+		if numadded, err := m.writeFfiStubFile(deffidirpath); err != nil {
+			panic(err)
+		} else if numadded > 0 {
+			me.sess.printf("%s: added %d FFI stub(s) to %s\n", m.qName, numadded, m.srcFilePath[:len(m.srcFilePath)-len(".purs")]+".go")
+		}
+	})
+}
+
+func (me *modPkg) writeFfiStubFile(deffidirpath string) (numadded int, err error) {
+	if me.coreimp == nil || me.coreimp.My.NamedRequires["$foreign"] == "" {
+		return
+	} else if deffidirpath != "" && ufs.FileExists(filepath.Join(deffidirpath, "ffi", "ps2go", strReplDot2Slash.Replace(me.qName), me.qName+".go")) {
+		return // covered by gonad's default FFI packages
+	}
+	stubfilepath := me.srcFilePath[:len(me.srcFilePath)-len(".purs")] + ".go"
+
+	//	gather what's already there: implementations (in all FFI files incl. build-tagged variants) and the stub file's imports
+	fset, existing, haveimps := token.NewFileSet(), map[string]bool{}, map[string]bool{}
+	var stubfile *ast.File
+	for _, ffifilepath := range me.ffiFilePaths {
+		var file *ast.File
+		if file, err = parser.ParseFile(fset, ffifilepath, nil, 0); err != nil {
+			return
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					existing[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					if vspec, _ := spec.(*ast.ValueSpec); vspec != nil {
+						for _, ident := range vspec.Names {
+							existing[ident.Name] = true
+						}
+					}
+				}
+			}
+		}
+		if ffifilepath == stubfilepath {
+			stubfile = file
+			for _, imp := range file.Imports {
+				haveimps[strings.Trim(imp.Path.Value, "\"`")] = true
+			}
+		}
+	}
+
+	//	render the missing ones
+	var stubs bytes.Buffer
+	var nuimps []string
+	irast := &irAst{mod: me, irM: me.irMeta}
+	for _, fvname := range me.irMeta.EnvForeignVals {
+		fvnamego := me.irMeta.goNameForForeignVal(fvname)
+		if existing[fvnamego] {
+			continue
+		}
+		gvd := me.irMeta.goValDeclByPsName(fvname)
+		if gvd == nil {
+			continue
+		}
+		var gotype string
+		var imps irMPkgRefs
+		if gvd.RefFunc != nil {
+			sig := &irANamedTypeRef{NameGo: fvnamego, RefFunc: &irATypeRefFunc{Rets: gvd.RefFunc.Rets, impl: ªBlock()}}
+			for i, arg := range gvd.RefFunc.Args {
+				sigarg := arg.nameless()
+				sigarg.NameGo = fmt.Sprintf("v%d", i)
+				sig.RefFunc.Args = append(sig.RefFunc.Args, sigarg)
+			}
+			gotype, imps = irast.codeGenTypeRefDetached(sig)
+			fmt.Fprintf(&stubs, "\n// %s implements foreign import `%s`.\n%s{\n\tpanic(\"not implemented\")\n}\n", fvnamego, fvname, gotype)
+		} else {
+			gotype, imps = irast.codeGenTypeRefDetached(gvd)
+			fmt.Fprintf(&stubs, "\n// %s implements foreign import `%s`.\nvar %s %s // not implemented\n", fvnamego, fvname, fvnamego, gotype)
+		}
+		for _, imp := range imps {
+			if !haveimps[imp.ImpPath] {
+				haveimps[imp.ImpPath], nuimps = true, append(nuimps, imp.ImpPath)
+			}
+		}
+		numadded++
+	}
+	if numadded == 0 {
+		return
+	}
+
+	//	then write: either a fresh file or the existing one with the new imports inserted and the stubs appended
+	sort.Strings(nuimps)
+	var impdecl bytes.Buffer
+	for _, imppath := range nuimps {
+		fmt.Fprintf(&impdecl, "\nimport %q\n", imppath)
+	}
+	var src []byte
+	if stubfile == nil {
+		src = []byte(fmt.Sprintf("// FFI for %s, stubs generated by gonad ffi-stubs.\n\npackage %s\n%s", me.qName, me.pName, impdecl.String()))
+	} else if src, err = ioutil.ReadFile(stubfilepath); err != nil {
+		return
+	} else {
+		pos := fset.Position(stubfile.Name.End()).Offset
+		src = append(append(append([]byte{}, src[:pos]...), impdecl.Bytes()...), src[pos:]...)
+	}
+	err = ufs.WriteBinaryFile(stubfilepath, append(src, stubs.Bytes()...))
+	return
+}
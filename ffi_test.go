@@ -0,0 +1,122 @@
+package gonad
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFfiGoFiles(t *testing.T) {
+	dirpath := t.TempDir()
+	for _, filename := range []string{"Foo.purs", "Foo.go", "Foo_windows.go", "Foo_linux_arm64.go", "Foo_Bar.go", "Foo_Bar.purs", "Foo_test.go", "Foo_linux_test.go", "Foo_linux_arm64_x.go"} {
+		benchWriteFile(t, filepath.Join(dirpath, filename), []byte("package ffi\n"))
+	}
+	got, want := findFfiGoFiles(filepath.Join(dirpath, "Foo.purs")), []string{"Foo.go", "Foo_linux_arm64.go", "Foo_windows.go"}
+	if len(got) != len(want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if filepath.Base(got[i]) != want[i] {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	}
+
+	mod := &modPkg{srcFilePath: filepath.Join(dirpath, "Foo.purs"), gopkgfilepath: filepath.Join(dirpath, "out", "Foo.go"), ffiFilePaths: got}
+	benchWriteFile(t, mod.gopkgfilepath, []byte("package Foo\n"))
+	for _, ffifilepath := range got {
+		benchWriteFile(t, filepath.Join(dirpath, "out", "ffi."+filepath.Base(ffifilepath)), []byte("package Foo\n"))
+	}
+	if mod.hasStaleFfiGoFiles() {
+		t.Error("FFI files considered stale right after copying")
+	}
+	if mod.ffiFilePaths = got[1:]; !mod.hasStaleFfiGoFiles() {
+		t.Error("deleted FFI file not noticed")
+	} else if err := mod.writeFfiGoFiles(); err != nil {
+		t.Fatal(err)
+	} else if copies := mod.ffiGoFileCopies(); len(copies) != 2 || filepath.Base(copies[0]) != "ffi.Foo_linux_arm64.go" {
+		t.Errorf("deleted FFI file's copy not removed: %v", copies)
+	}
+}
+
+// the golden ffi scenario's Go FFI has `string`s, which in utf16 mode must be caught as mismatching `𝒈.Str`s
+func TestFfiCheckStrRepr(t *testing.T) {
+	dirpath := t.TempDir()
+	srcdirpath, outdirpath := filepath.Join(dirpath, "src"), filepath.Join(dirpath, "output")
+	goldenCopyDir(t, filepath.Join("testdata", "golden", "ffi", "src"), srcdirpath)
+	goldenCopyDir(t, filepath.Join("testdata", "golden", "ffi", "output"), outdirpath)
+	bowerfilepath := filepath.Join(dirpath, "bower.json")
+	benchWriteFile(t, bowerfilepath, []byte(fmt.Sprintf(`{"name": "gonad-ffi", "Gonad": {"CodeGen": {"StringRepr": "utf16"}, "In": {"CoreFilesDirPath": %q}, "Out": {"GoDirSrcPath": %q, "GoNamespaceProj": "ffi"}}}`,
+		outdirpath, filepath.Join(dirpath, "gopath", "src"))))
+	sess := benchLoadProj(t, bowerfilepath, srcdirpath)
+	for _, phase := range benchPhases {
+		if phase.name != "codegen" {
+			phase.run(&sess.proj)
+		}
+	}
+	if mod := sess.proj.Modules[0]; len(mod.diags) != 1 || !strings.Contains(mod.diags[0].Message, "foreign import `shout` expected to be of type `func(𝒈.Str) 𝒈.Str`") {
+		t.Errorf("expected just the `shout` type mismatch, got %v", mod.diags)
+	}
+}
+
+// FFI signatures referring to generated types of imported modules (here, the foreign data type Effect.Effect) are checked too, else warned about
+func TestFfiCheckForeignTypes(t *testing.T) {
+	ffipkgsdirpath, err := filepath.Abs(dirNameDefaultFfiPkgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const imps = "import (\n\t\"conformance/Effect\"\n\t\"github.com/gonadz/g/ffi/ps2go/Effect\"\n\t\"example.com/unknown\"\n)\n\nfunc Shout(s string) string { return s }\n\n"
+	for greet, want := range map[string]string{
+		"func Greet(s string) Effect.Effect { panic(s) }":      "",
+		"func Greet(s string) 𝙜ˈEffect.Effect { panic(s) }":    "",
+		"func Greet(s string) func() interface{} { panic(s) }": "foreign import `greet` expected to be of type `func(string) Effect.Effect`",
+		"func Greet(s string) unknown.Effect { panic(s) }":     "warning: foreign import `greet` not type-checked",
+	} {
+		dirpath, _ := conformanceSetup(t, filepath.Join("testdata", "conformance", "ffi", "user"))
+		coreimpfilepath := filepath.Join(dirpath, "output", "Main", "coreimp.json")
+		var coreimp map[string]interface{}
+		if err = json.Unmarshal(goldenReadFile(t, coreimpfilepath), &coreimp); err != nil {
+			t.Fatal(err)
+		}
+		//	foreign import greet :: String -> Effect Unit
+		tcon := func(name string) map[string]interface{} {
+			return map[string]interface{}{"Tag": "TypeConstructor", "Text": name}
+		}
+		tapp := func(t0 interface{}, t1 interface{}) map[string]interface{} {
+			return map[string]interface{}{"Tag": "TypeApp", "Type0": t0, "Type1": t1}
+		}
+		coreimp["DeclEnv"].(map[string]interface{})["Functions"].(map[string]interface{})["greet"] = map[string]interface{}{
+			"Type": tapp(tapp(tcon("Prim.Function"), tcon("Prim.String")), tapp(tcon("Effect.Effect"), tcon("Data.Unit.Unit")))}
+		coreimpjson, _ := json.Marshal(coreimp)
+		benchWriteFile(t, coreimpfilepath, coreimpjson)
+		benchWriteFile(t, filepath.Join(dirpath, "src", "Main.go"), []byte("package ffi\n\n"+imps+greet+"\n\nvar _ = unknown.X\nvar _ 𝙜ˈEffect.Effect\n"))
+
+		result, _ := Compile(context.Background(), Options{BowerJsonFilePath: filepath.Join(dirpath, "bower.json"), SrcDirPath: filepath.Join(dirpath, "src"),
+			DepsDirPath: filepath.Join(dirpath, "bower_components"), FfiPkgsPath: ffipkgsdirpath, NoPrefix: true})
+		if result == nil {
+			t.Fatalf("%s: no result", greet)
+		}
+		var got []*Diagnostic
+		for _, mod := range result.Modules {
+			got = append(got, mod.Diagnostics...)
+		}
+		if wantwarning := strings.HasPrefix(want, "warning: "); want == "" && len(got) > 0 {
+			t.Errorf("%s: want no diagnostics, got %v", greet, got)
+		} else if want != "" && (len(got) != 1 || got[0].Warning != wantwarning || !strings.Contains(got[0].Message, strings.TrimPrefix(want, "warning: "))) {
+			t.Errorf("%s: want just `%s`, got %v", greet, want, got)
+		}
+	}
+}
+
+// user-supplied FFI (here with an underscore-named foreign import) and the default FFI packages must link with the generated code
+func TestFfiEndToEnd(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command found: " + err.Error())
+	}
+	if stage, err := conformanceCheck(t, filepath.Join("testdata", "conformance", "ffi", "underscore")); err != nil {
+		t.Fatalf("%s: %v", stage, err)
+	}
+}
@@ -0,0 +1,260 @@
+package gonad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"testing"
+
+	"github.com/metaleap/go-util/dev/ps"
+)
+
+/*
+Fuzz targets for coreimp ingestion and the IR passes, eg.
+`go test -run - -fuzz FuzzCoreImpAst`. The modules of all
+testdata/golden scenarios are loaded (and their irMetas
+populated) once, as one project; then each input picks one
+of them and swaps in its own coreimp Body: decoded from
+(possibly mangled) coreimp.json bytes, then mutated at the
+AST level by the given mutation ops. That goes through
+astToIrA, prepFromCoreImp and finalizePostPrepOps (without
+--verify-ir, as mutants readily break invariants such as
+unique decls by themselves), while the module's DeclEnv,
+imports and exports stay valid. Unsupported inputs may panic with a notImplErr
+diagnostic, but any other panic counts as a failure. The
+seed corpus is every golden module's coreimp.json, both
+unchanged and with a few mutation ops.
+*/
+
+const fuzzMaxMutations = 32
+
+var (
+	fuzzAstTags = []string{"StringLiteral", "BooleanLiteral", "NumberLiteral", "IntegerLiteral", "Var", "Block", "While", "ForIn", "For", "IfElse", "App",
+		"VariableIntroduction", "Function", "Unary", "Binary", "Comment", "ObjectLiteral", "ReturnNoResult", "Return", "Throw", "ArrayLiteral", "Assignment", "Indexer", "InstanceOf", ""}
+	fuzzAstOps = []string{"Negate", "Not", "Positive", "BitwiseNot", "New", "Add", "Subtract", "Multiply", "Divide", "Modulus", "EqualTo", "NotEqualTo", "LessThan",
+		"LessThanOrEqualTo", "GreaterThan", "GreaterThanOrEqualTo", "And", "Or", "BitwiseAnd", "BitwiseOr", "BitwiseXor", "ShiftLeft", "ShiftRight", "ZeroFillShiftRight", ""}
+)
+
+type fuzzModule struct {
+	mod     *modPkg
+	ext     *udevps.Extern
+	coreimp []byte // the original coreimp.json, for a fresh DeclEnv every run
+}
+
+// fuzzSlot is one (non-nil) node of a coreimp AST, along with how to replace it in its parent
+type fuzzSlot struct {
+	node *udevps.CoreImpAst
+	set  func(*udevps.CoreImpAst)
+}
+
+func FuzzCoreImpAst(f *testing.F) {
+	mods := fuzzLoadGoldenModules(f)
+	for _, qname := range fuzzSortedKeys(mods) {
+		f.Add(qname, mods[qname].coreimp, []byte{})
+		f.Add(qname, mods[qname].coreimp, []byte{0, 7, 0, 1, 3, 11, 2, 5, 4})
+		f.Add(qname, mods[qname].coreimp, []byte{4, 2, 0, 5, 9, 1, 3, 4, 13})
+	}
+	f.Fuzz(func(t *testing.T, qname string, coreimpjson []byte, mutations []byte) {
+		var fuzzed struct{ Body []*udevps.CoreImpAst }
+		if json.Unmarshal(coreimpjson, &fuzzed) != nil {
+			return // not even JSON: not what we are after here
+		}
+		fm := fuzzPickModule(mods, qname)
+		mod := fm.mod
+		mod.ext, mod.coreimp = fm.ext, &psCoreImp{mod: mod}
+		if err := json.Unmarshal(fm.coreimp, &mod.coreimp.CoreImp); err != nil {
+			t.Fatal(err)
+		}
+		mod.coreimp.My.ImpFilePath, mod.coreimp.Body = mod.impFilePath, fuzzMutate(fuzzed.Body, mutations)
+		mod.coreimp.PrepTopLevel()
+		fuzzRun(t, func() {
+			mod.irMeta = &irMeta{isDirty: true, mod: mod, proj: mod.proj}
+			mod.irMeta.populateFromCoreImp()
+			mod.prepIrAst()
+			mod.reGenPkgIrAst()
+		})
+	})
+}
+
+// fuzzRun fails t on any panic in run except those carrying a notImplErr diagnostic
+func fuzzRun(t *testing.T, run func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isnotimpl := r.(*notImplError); !isnotimpl {
+				t.Fatalf("panic: %v\n%s", r, debug.Stack())
+			}
+		}
+	}()
+	run()
+}
+
+// fuzzLoadGoldenModules sets up all testdata/golden scenarios as one project, with all irMetas populated
+func fuzzLoadGoldenModules(f *testing.F) map[string]*fuzzModule {
+	scenariodirpaths, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	dirpath := f.TempDir()
+	srcdirpath, outdirpath := filepath.Join(dirpath, "src"), filepath.Join(dirpath, "output")
+	for _, scenariodirpath := range scenariodirpaths {
+		goldenCopyDir(f, filepath.Join(scenariodirpath, "src"), srcdirpath)
+		goldenCopyDir(f, filepath.Join(scenariodirpath, "output"), outdirpath)
+	}
+	bowerfilepath := filepath.Join(dirpath, "bower.json")
+	benchWriteFile(f, bowerfilepath, []byte(fmt.Sprintf(`{"name": "gonad-fuzz", "Gonad": {"In": {"CoreFilesDirPath": %q}, "Out": {"GoDirSrcPath": %q, "GoNamespaceProj": "fuzz"}}}`,
+		outdirpath, filepath.Join(dirpath, "gopath", "src"))))
+	sess := benchLoadProj(f, bowerfilepath, srcdirpath)
+	for _, phase := range benchPhases[:2] { // load, populate
+		phase.run(&sess.proj)
+	}
+
+	mods := map[string]*fuzzModule{}
+	for _, mod := range sess.proj.Modules {
+		fm := &fuzzModule{mod: mod, ext: &udevps.Extern{}}
+		if fm.coreimp, err = ioutil.ReadFile(mod.impFilePath); err == nil {
+			err = jsonDecodeFileStreamed(mod.extFilePath, fm.ext, "EfVersion", "EfModuleName", "EfExports")
+		}
+		if err != nil {
+			f.Fatal(err)
+		}
+		mods[mod.qName] = fm
+	}
+	if len(mods) == 0 {
+		f.Fatal("no modules in testdata/golden")
+	}
+	return mods
+}
+
+// fuzzPickModule falls back to some deterministic other module when the fuzzer has mangled qname
+func fuzzPickModule(mods map[string]*fuzzModule, qname string) *fuzzModule {
+	if fm := mods[qname]; fm != nil {
+		return fm
+	}
+	qnames := fuzzSortedKeys(mods)
+	return mods[qnames[len(qname)%len(qnames)]]
+}
+
+func fuzzSortedKeys(mods map[string]*fuzzModule) (qnames []string) {
+	for qname := range mods {
+		qnames = append(qnames, qname)
+	}
+	sort.Strings(qnames)
+	return
+}
+
+// fuzzMutate applies up to fuzzMaxMutations ops to body, each taking 3 bytes: which op, which node and an op-specific operand
+func fuzzMutate(body []*udevps.CoreImpAst, mutations []byte) []*udevps.CoreImpAst {
+	for i := 0; i+2 < len(mutations) && i < 3*fuzzMaxMutations; i += 3 {
+		slots := fuzzSlots(body, func(nubody []*udevps.CoreImpAst) { body = nubody })
+		if len(slots) == 0 {
+			break
+		}
+		slot, operand := slots[int(mutations[i+1])%len(slots)], int(mutations[i+2])
+		switch mutations[i] % 6 {
+		case 0:
+			slot.set(nil)
+		case 1: // replace the node by a copy of any other
+			slot.set(fuzzCopyAst(slots[operand%len(slots)].node))
+		case 2:
+			slot.node.AstTag = fuzzAstTags[operand%len(fuzzAstTags)]
+		case 3:
+			slot.node.AstOp = fuzzAstOps[operand%len(fuzzAstOps)]
+		case 4: // swap in some other name from anywhere in the AST
+			names := []string{""}
+			for _, s := range slots {
+				names = append(names, s.node.Var, s.node.Function, s.node.VariableIntroduction)
+			}
+			switch name := names[operand%len(names)]; operand % 3 {
+			case 0:
+				slot.node.Var = name
+			case 1:
+				slot.node.Function = name
+			default:
+				slot.node.VariableIntroduction = name
+			}
+		case 5: // change the arity of calls, funcs, blocks or array literals
+			switch n := slot.node; operand % 4 {
+			case 0:
+				n.AstApplArgs = fuzzResize(n.AstApplArgs, operand)
+			case 1:
+				if len(n.AstFuncParams) > 0 && operand&4 == 0 {
+					n.AstFuncParams = n.AstFuncParams[:len(n.AstFuncParams)-1]
+				} else {
+					n.AstFuncParams = append(n.AstFuncParams, fmt.Sprintf("v%d", operand))
+				}
+			case 2:
+				n.Block = fuzzResize(n.Block, operand)
+			default:
+				n.ArrayLiteral = fuzzResize(n.ArrayLiteral, operand)
+			}
+		}
+	}
+	return body
+}
+
+// fuzzResize drops the last node or duplicates the first
+func fuzzResize(nodes []*udevps.CoreImpAst, operand int) []*udevps.CoreImpAst {
+	if len(nodes) > 0 && operand&4 == 0 {
+		return nodes[:len(nodes)-1]
+	} else if len(nodes) > 0 {
+		return append(nodes, fuzzCopyAst(nodes[0]))
+	}
+	return nodes
+}
+
+// fuzzCopyAst deep-copies via JSON, which works as long as Root and Parent are not yet set (by InitAstOnLoaded)
+func fuzzCopyAst(a *udevps.CoreImpAst) (cp *udevps.CoreImpAst) {
+	if jsonbytes, err := json.Marshal(a); err != nil {
+		panic(err)
+	} else if err = json.Unmarshal(jsonbytes, &cp); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// fuzzSlots lists all nodes of body in pre-order: dropping one (setting nil) removes it from its slice, if any, or leaves nil in its parent's field
+func fuzzSlots(body []*udevps.CoreImpAst, setBody func([]*udevps.CoreImpAst)) (slots []fuzzSlot) {
+	var walk func(*udevps.CoreImpAst, func(*udevps.CoreImpAst))
+	walkAll := func(nodes []*udevps.CoreImpAst, setNodes func([]*udevps.CoreImpAst)) {
+		for i := range nodes {
+			i := i
+			walk(nodes[i], func(a *udevps.CoreImpAst) {
+				if a == nil {
+					setNodes(append(nodes[:i:i], nodes[i+1:]...))
+				} else {
+					nodes[i] = a
+				}
+			})
+		}
+	}
+	walk = func(a *udevps.CoreImpAst, set func(*udevps.CoreImpAst)) {
+		if a == nil {
+			return
+		}
+		slots = append(slots, fuzzSlot{node: a, set: set})
+		for _, p := range []**udevps.CoreImpAst{&a.While, &a.IfElse, &a.App, &a.Unary, &a.Binary, &a.Return, &a.Throw, &a.Assignment, &a.Indexer, &a.InstanceOf,
+			&a.AstBody, &a.AstRight, &a.AstFor1, &a.AstFor2, &a.AstThen, &a.AstElse} {
+			p := p
+			walk(*p, func(nu *udevps.CoreImpAst) { *p = nu })
+		}
+		walkAll(a.Block, func(nodes []*udevps.CoreImpAst) { a.Block = nodes })
+		walkAll(a.ArrayLiteral, func(nodes []*udevps.CoreImpAst) { a.ArrayLiteral = nodes })
+		walkAll(a.AstApplArgs, func(nodes []*udevps.CoreImpAst) { a.AstApplArgs = nodes })
+		for _, namevaluepair := range a.ObjectLiteral {
+			keys := make([]string, 0, len(namevaluepair))
+			for k := range namevaluepair {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys) // map order would make the ops of an input hit different nodes from run to run
+			for _, k := range keys {
+				m, k := namevaluepair, k
+				walk(m[k], func(nu *udevps.CoreImpAst) { m[k] = nu })
+			}
+		}
+	}
+	walkAll(body, setBody)
+	return
+}
@@ -1,12 +1,15 @@
-package main
+package gonad
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/metaleap/go-util/dev/ps"
 	"github.com/metaleap/go-util/str"
@@ -52,16 +55,52 @@ func (me *irAst) codeGenAst(w io.Writer, indent int, ast irA) {
 	}
 	switch a := ast.(type) {
 	case *irALitStr:
-		fmt.Fprintf(w, "%q", a.LitStr)
+		if a.ExprType() == exprTypeChar {
+			//	a code unit for utf16 (see gonadz/str-utf16.go) or a rune for utf8: either way, purs only makes Char literals of single code units
+			units := utf16.Encode([]rune(a.LitStr))
+			if len(units) != 1 {
+				panic(notImplErr("Char literal", strconv.Quote(a.LitStr), me.mod.srcFilePath))
+			} else if me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16 {
+				me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
+				fmt.Fprintf(w, "𝒈.Char(0x%04x)", units[0])
+			} else {
+				fmt.Fprintf(w, "%q", rune(units[0]))
+			}
+		} else if me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16 && !a.goNative {
+			//	a (typed, still constable) conversion of the big-endian UTF-16 code units, see gonadz/str-utf16.go
+			me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
+			units := utf16.Encode([]rune(a.LitStr))
+			buf := make([]byte, 0, 2*len(units))
+			for _, unit := range units {
+				buf = append(buf, byte(unit>>8), byte(unit))
+			}
+			fmt.Fprintf(w, "𝒈.Str(%q)", buf)
+		} else {
+			fmt.Fprintf(w, "%q", a.LitStr)
+		}
 	case *irALitBool:
 		fmt.Fprintf(w, "%t", a.LitBool)
 	case *irALitNum:
-		s := fmt.Sprintf("%f", a.LitNum)
-		for strings.HasSuffix(s, "0") {
-			s = s[:len(s)-1]
+		if negzero := a.LitNum == 0 && math.Signbit(a.LitNum); negzero || math.IsInf(a.LitNum, 0) || math.IsNaN(a.LitNum) {
+			me.irM.ensureImp("math", "", "").emitted = true
+			if negzero {
+				fmt.Fprint(w, "math.Copysign(0, -1)") // a -0.0 literal would be a constant +0
+			} else if math.IsNaN(a.LitNum) {
+				fmt.Fprint(w, "math.NaN()")
+			} else if a.LitNum > 0 {
+				fmt.Fprint(w, "math.Inf(1)")
+			} else {
+				fmt.Fprint(w, "math.Inf(-1)")
+			}
+		} else if s := strconv.FormatFloat(a.LitNum, 'g', -1, 64); strings.ContainsAny(s, ".e") {
+			fmt.Fprint(w, s) // shortest repr that parses back into the exact same float64
+		} else {
+			fmt.Fprint(w, s+".0") // keeps the literal a float in untyped contexts
 		}
-		fmt.Fprint(w, s)
 	case *irALitInt:
+		if a.LitInt < math.MinInt32 || a.LitInt > math.MaxInt32 {
+			panic(notImplErr("Int literal out of int32 range", strconv.Itoa(a.LitInt), me.mod.srcFilePath))
+		}
 		fmt.Fprintf(w, "%d", a.LitInt)
 	case *irALitArr:
 		me.codeGenTypeRef(w, &a.irANamedTypeRef, indent)
@@ -107,7 +146,7 @@ func (me *irAst) codeGenAst(w io.Writer, indent int, ast irA) {
 			fmt.Fprint(w, " := ")
 			me.codeGenAst(w, indent, ato)
 		default:
-			if at := a.ExprType(); at.RefFunc != nil && a.LetVal != nil {
+			if at := a.ExprType(); at.RefFunc != nil && a.LetVal != nil && !a.isTopLevel() {
 				fmt.Fprintf(w, "%s%s := ", tabs, a.NameGo)
 				me.codeGenAst(w, indent, a.LetVal)
 			} else {
@@ -178,9 +217,25 @@ func (me *irAst) codeGenAst(w io.Writer, indent int, ast irA) {
 			fmt.Fprint(w, "\n")
 		}
 	case *irAPanic:
+		//	all throws panic with an *𝒈.Error (see gonadz/err.go), so that Go callers and Effect.Exception can recover uniformly
 		fmt.Fprintf(w, "%spanic(", tabs)
-		me.codeGenAst(w, indent, a.PanicArg)
+		if a.isErrCtor() {
+			me.codeGenAst(w, indent, a.PanicArg)
+		} else {
+			me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
+			fmt.Fprint(w, "𝒈.ErrOf(")
+			me.codeGenAst(w, indent, a.PanicArg)
+			fmt.Fprint(w, ")")
+		}
 		fmt.Fprint(w, ")\n")
+	case *irAPatMatchFail:
+		me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
+		fmt.Fprintf(w, "𝒈.PatternMatchFail(%q, %d, %d, %d, %d", a.ModQName, a.SrcPos[0], a.SrcPos[1], a.SrcPos[2], a.SrcPos[3])
+		for _, val := range a.Scrutinees {
+			fmt.Fprint(w, ", ")
+			me.codeGenAst(w, indent, val)
+		}
+		fmt.Fprint(w, ")")
 	case *irADot:
 		me.codeGenAst(w, indent, a.DotLeft)
 		fmt.Fprint(w, ".")
@@ -226,6 +281,15 @@ func (me *irAst) codeGenAst(w io.Writer, indent int, ast irA) {
 			fmt.Fprint(w, ")")
 		}
 	case *irAOp2:
+		if intrinsic := a.goIntrinsic(); intrinsic != "" {
+			me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
+			fmt.Fprintf(w, "𝒈.%s(", intrinsic)
+			me.codeGenAst(w, indent, a.Left)
+			fmt.Fprint(w, ", ")
+			me.codeGenAst(w, indent, a.Right)
+			fmt.Fprint(w, ")")
+			break
+		}
 		po1, po2 := a.parentOp()
 		parens := po1 != nil || (po2 != nil && (po2.Op2 != a.Op2 || (a.Op2 != "+" && a.Op2 != "*" && a.Op2 != "&&" && a.Op2 != "&" && a.Op2 != "||" && a.Op2 != "|")))
 		if parens {
@@ -314,6 +378,24 @@ func (me *irAst) codeGenGroupedVals(w io.Writer, consts bool, asts []irA) {
 	}
 }
 
+func (me *irAst) codeGenDeferredVals(w io.Writer, lets []*irALet) {
+	if len(lets) > 0 {
+		fmt.Fprint(w, "var (\n")
+		for _, a := range lets {
+			fmt.Fprintf(w, "\t%s ", a.NameGo)
+			me.codeGenTypeRef(w, a.ExprType(), 1)
+			fmt.Fprint(w, "\n")
+		}
+		fmt.Fprint(w, ")\n\nfunc init() {\n")
+		for _, a := range lets {
+			fmt.Fprintf(w, "\t%s = ", a.NameGo)
+			me.codeGenAst(w, 1, a.LetVal)
+			fmt.Fprint(w, "\n")
+		}
+		fmt.Fprint(w, "}\n\n")
+	}
+}
+
 // func (_ *irAst) codeGenEnumConsts(w io.Writer, enumconstnames []string, enumconsttype string) {
 // 	fmt.Fprint(w, "const (\n")
 // 	fmt.Fprintf(w, "\t_ %v= iota\n", strings.Repeat(" ", len(enumconsttype)+len(enumconstnames[0])))
@@ -413,10 +495,38 @@ func (me *irAst) codeGenStructMethods(w io.Writer, tr *irANamedTypeRef) {
 
 func (me *irAst) codeGenTypeDef(w io.Writer, gtd *irANamedTypeRef) {
 	fmt.Fprintf(w, "type %s ", gtd.NameGo)
+	if strings.HasPrefix(gtd.RefAlias, prefixDefaultFfiPkgNs) { // foreign data: must be the very same type as in the default FFI package, whose funcs we re-export
+		fmt.Fprint(w, "= ")
+	}
 	me.codeGenTypeRef(w, gtd, 0)
 	fmt.Fprint(w, "\n\n")
 }
 
+// codeGenTypeRefDetached renders gtd like codeGenTypeRef does, but without
+// leaving traces in our irMeta's imports: those it needed are returned instead.
+func (me *irAst) codeGenTypeRefDetached(gtd *irANamedTypeRef) (gotype string, imps irMPkgRefs) {
+	origimports, origimps, origdirty := me.irM.imports, me.irM.Imports, me.irM.isDirty
+	origemitted := make([]bool, len(origimps))
+	for i, imp := range origimps {
+		origemitted[i], imp.emitted = imp.emitted, false
+	}
+	me.irM.Imports = append(irMPkgRefs{}, origimps...)
+
+	var buf bytes.Buffer
+	me.codeGenTypeRef(&buf, gtd, -1)
+	for _, imp := range me.irM.Imports {
+		if imp.emitted {
+			imps = append(imps, &irMPkgRef{GoName: imp.GoName, ImpPath: imp.ImpPath, PsModQName: imp.PsModQName})
+		}
+	}
+
+	for i, imp := range origimps {
+		imp.emitted = origemitted[i]
+	}
+	me.irM.imports, me.irM.Imports, me.irM.isDirty = origimports, origimps, origdirty
+	return buf.String(), imps
+}
+
 func (me *irAst) codeGenTypeRef(w io.Writer, gtd *irANamedTypeRef, indlevel int) {
 	if gtd == nil {
 		fmt.Fprint(w, "interface{/*NIL*/}")
@@ -425,7 +535,7 @@ func (me *irAst) codeGenTypeRef(w io.Writer, gtd *irANamedTypeRef, indlevel int)
 	fmtembeds := "\t%s\n"
 	isfuncwithbodynotjustsig := gtd.RefFunc != nil && gtd.RefFunc.impl != nil
 	if gtd.RefAlias != "" {
-		me.codeGenAst(w, -1, ªPkgSym(me.resolveGoTypeRefFromQName(gtd.RefAlias)))
+		me.codeGenAst(w, -1, me.pkgSym(me.resolveGoTypeRefFromQName(gtd.RefAlias)))
 	} else if gtd.RefUnknown != 0 {
 		fmt.Fprintf(w, "interface{/UNKNOWN:*%d*/}", gtd.RefUnknown)
 	} else if gtd.RefArray != nil {
@@ -438,7 +548,7 @@ func (me *irAst) codeGenTypeRef(w io.Writer, gtd *irANamedTypeRef, indlevel int)
 		if len(gtd.RefInterface.Embeds) == 0 && len(gtd.RefInterface.Methods) == 0 {
 			if gtd.RefInterface.isTypeVar {
 				fmt.Fprint(w, "𝒈.𝑻")
-				me.irM.ensureImp("", "github.com/gonadz/-", "").emitted = true
+				me.irM.ensureImp("", impPathDefaultFfiRoot, "").emitted = true
 			} else {
 				fmt.Fprint(w, "interface{}")
 			}
@@ -451,7 +561,7 @@ func (me *irAst) codeGenTypeRef(w io.Writer, gtd *irANamedTypeRef, indlevel int)
 			if areOverlappingInterfacesSupportedByGo {
 				for _, ifembed := range gtd.RefInterface.Embeds {
 					fmt.Fprint(w, tabind+"\t")
-					me.codeGenAst(w, -1, ªPkgSym(me.resolveGoTypeRefFromQName(ifembed)))
+					me.codeGenAst(w, -1, me.pkgSym(me.resolveGoTypeRefFromQName(ifembed)))
 					fmt.Fprint(w, "\n")
 				}
 			}
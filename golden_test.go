@@ -0,0 +1,277 @@
+package gonad
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/metaleap/go-util/fs"
+)
+
+/*
+Golden-file tests of the whole translation. Every dir in
+testdata/golden is one scenario: a PureScript project's
+src (*.purs and any user-supplied FFI *.go) and the output
+dir of coreimp.json and externs.json files that purs made
+from it as the inputs (plus optionally a facades.json, the
+Gonad.Out.Facades to configure), and, in its want dir, all
+outputs expected from gonad: for each module, the generated
+package files and the gonad.json, plus gonad-symbols.json and
+any facade packages (each suffixed .golden, keeping go
+tooling off them). Running `go test -run Golden -update`
+re-writes the want files from the current translation, so
+that any change in behavior shows up as a reviewable diff.
+Beyond that, no module may fail and all generated Go must
+type-check (see goldenImporter), except in the scenarios of
+goldenKnownBroken: these have no wants for their generated
+Go, rather than pinning down output known to be wrong. Once
+such a scenario's Go type-checks, the test insists on its
+removal from goldenKnownBroken (and an -update).
+*/
+
+var goldenUpdate = flag.Bool("update", false, "re-write the testdata/golden/*/want files from the current translation")
+
+// by scenario, why its generated Go is not (yet) valid: type errors are then only logged, not failed
+var goldenKnownBroken = map[string]string{
+	"adts":     "ctor applications left as calls of the JS ctor's curried `create`, on an undeclared `rect`: `rect.create(1.0)(1.0)`",
+	"classes":  "type-class dicts and their members' args typed `𝒈.𝑻`, so eg. `x + y` on those doesn't compile",
+	"loops":    "TCO loops become nested `func ᵒtco_loop` decls, with a `const` both re-assigned and type-asserted, and loops never exit",
+	"newtypes": "newtype values not converted to and from the wrapped type, eg. an `Age` returned as `int32`",
+	"records":  "record literals of type `interface{/*EMPTY*/}`, eg. `interface{/*EMPTY*/}{name: …}`",
+}
+
+const (
+	goldenBuild      = "golden" // stamped into every gonad.json instead of whatever curGonadBuild makes of the test executable
+	goldenFileSuffix = ".golden"
+)
+
+func TestGolden(t *testing.T) {
+	scenariodirpaths, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(scenariodirpaths) == 0 {
+		t.Fatal("no scenarios in testdata/golden")
+	}
+	for _, dirpath := range scenariodirpaths {
+		t.Run(filepath.Base(dirpath), func(t *testing.T) {
+			got, wantdirpath := goldenTranslate(t, dirpath), filepath.Join(dirpath, "want")
+			if *goldenUpdate {
+				goldenWriteWant(t, wantdirpath, got)
+			} else {
+				goldenCompare(t, wantdirpath, got)
+			}
+		})
+	}
+}
+
+// goldenTranslate runs the whole pipeline over a temp-dir copy of the scenario's inputs, returning all outputs by their want-relative file paths
+func goldenTranslate(t *testing.T, scenariodirpath string) (outputs map[string][]byte) {
+	bowerfilepath, srcdirpath := goldenSetup(t, scenariodirpath)
+	sess := benchLoadProj(t, bowerfilepath, srcdirpath)
+	if sess.flag.NoPrefix, sess.flag.Build = true, goldenBuild; len(sess.proj.Modules) == 0 { // the header comment has our temp-dir paths in it
+		t.Fatalf("%s: no modules found", scenariodirpath)
+	}
+	for _, phase := range benchPhases {
+		if phase.name != "codegen" {
+			phase.run(&sess.proj)
+		}
+	}
+	for _, mod := range sess.proj.Modules {
+		for _, diag := range mod.diags {
+			if !diag.Warning {
+				t.Errorf("%s: %v", scenariodirpath, diag)
+			}
+		}
+	}
+	goldenTypeCheck(t, scenariodirpath, bowerfilepath)
+	return goldenOutputs(t, scenariodirpath, bowerfilepath)
+}
+
+// goldenSetup copies the scenario's inputs into a temp dir, next to a bower.json with its facades.json (if any) and outputs going to that temp dir's gopath
+func goldenSetup(t *testing.T, scenariodirpath string) (bowerfilepath string, srcdirpath string) {
+	dirpath := t.TempDir()
+	srcdirpath, outdirpath, gosrcdirpath := filepath.Join(dirpath, "src"), filepath.Join(dirpath, "output"), filepath.Join(dirpath, "gopath", "src")
+	goldenCopyDir(t, filepath.Join(scenariodirpath, "src"), srcdirpath)
+	goldenCopyDir(t, filepath.Join(scenariodirpath, "output"), outdirpath)
+	var facades []string
+	if facadesjson, err := ioutil.ReadFile(filepath.Join(scenariodirpath, "facades.json")); err == nil {
+		if err = json.Unmarshal(facadesjson, &facades); err != nil {
+			t.Fatal(err)
+		}
+	}
+	facadesjson, _ := json.Marshal(facades)
+	bowerfilepath = filepath.Join(dirpath, "bower.json")
+	benchWriteFile(t, bowerfilepath, []byte(fmt.Sprintf(`{"name": "gonad-golden", "Gonad": {"In": {"CoreFilesDirPath": %q}, "Out": {"GoDirSrcPath": %q, "GoNamespaceProj": "golden", "Facades": %s}}}`,
+		outdirpath, gosrcdirpath, facadesjson)))
+	return
+}
+
+// goldenOutputs collects all that got generated for the scenario set up by goldenSetup: every gonad.json, and all files in the project's Go package dir
+func goldenOutputs(t *testing.T, scenariodirpath string, bowerfilepath string) (outputs map[string][]byte) {
+	dirpath := filepath.Dir(bowerfilepath)
+	outputs = map[string][]byte{}
+	irmetafilepaths, err := filepath.Glob(filepath.Join(dirpath, "output", "*", "gonad.json"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(irmetafilepaths) == 0 {
+		t.Fatalf("%s: no modules translated", scenariodirpath)
+	}
+	for _, irmetafilepath := range irmetafilepaths {
+		outputs[filepath.Base(filepath.Dir(irmetafilepath))+".gonad.json"] = goldenReadFile(t, irmetafilepath)
+	}
+	pkgdirpath := filepath.Join(dirpath, "gopath", "src", "golden")
+	ufs.WalkAllFiles(pkgdirpath, func(filepath string) bool {
+		outputs[strings.TrimLeft(filepath[len(pkgdirpath):], "\\/")] = goldenReadFile(t, filepath)
+		return true
+	})
+
+	if goldenKnownBroken[filepath.Base(scenariodirpath)] != "" {
+		//	no wants of output known to be wrong: just the metas get compared
+		for relpath := range outputs {
+			if strings.HasSuffix(relpath, ".go") {
+				delete(outputs, relpath)
+			}
+		}
+	}
+	return
+}
+
+// goldenTypeCheck type-checks all packages generated for the scenario set up by goldenSetup (facades included), against the default FFI packages as deployed for them
+func goldenTypeCheck(t *testing.T, scenariodirpath string, bowerfilepath string) {
+	imp := &goldenImporter{fset: token.NewFileSet(), gosrcdirpath: filepath.Join(filepath.Dir(bowerfilepath), "gopath", "src"), pkgs: map[string]*types.Package{}}
+	imp.std = importer.ForCompiler(imp.fset, "source", nil)
+	pkgdirpath, imppaths := filepath.Join(imp.gosrcdirpath, "golden"), map[string]bool{}
+	ufs.WalkAllFiles(pkgdirpath, func(filepath string) bool {
+		if strings.HasSuffix(filepath, ".go") {
+			imppaths["golden"+path.Dir(strings.Replace(filepath[len(pkgdirpath):], "\\", "/", -1))] = true
+		}
+		return true
+	})
+	var typeerrs []string
+	for imppath := range imppaths {
+		if _, err := imp.Import(imppath); err != nil {
+			typeerrs = append(typeerrs, err.Error())
+		}
+	}
+	sort.Strings(typeerrs)
+	if knownbroken := goldenKnownBroken[filepath.Base(scenariodirpath)]; knownbroken == "" {
+		for _, typeerr := range typeerrs {
+			t.Errorf("%s: generated Go does not type-check: %s", scenariodirpath, typeerr)
+		}
+	} else if len(typeerrs) == 0 {
+		t.Errorf("%s: listed in goldenKnownBroken, but all its generated Go type-checks: remove it there, then re-run with -update", scenariodirpath)
+	} else {
+		t.Logf("%s: known broken (%s): %s", scenariodirpath, knownbroken, strings.Join(typeerrs, "; "))
+	}
+}
+
+// goldenImporter type-checks from source both the packages generated into gosrcdirpath and gonad's default FFI packages (right from this repo's gonadz dir, in their utf8 StringRepr variant), with std packages left to std
+type goldenImporter struct {
+	fset         *token.FileSet
+	gosrcdirpath string
+	std          types.Importer
+	pkgs         map[string]*types.Package
+}
+
+func (me *goldenImporter) Import(imppath string) (pkg *types.Package, err error) {
+	if pkg = me.pkgs[imppath]; pkg != nil {
+		return
+	}
+	dirpath := filepath.Join(me.gosrcdirpath, filepath.FromSlash(imppath))
+	if imppath == impPathDefaultFfiRoot || strings.HasPrefix(imppath, impPathDefaultFfiRoot+"/") {
+		dirpath = filepath.Join(dirNameDefaultFfiPkgs, filepath.FromSlash(imppath[len(impPathDefaultFfiRoot):]))
+	} else if !ufs.DirExists(dirpath) {
+		return me.std.Import(imppath)
+	}
+	bpkg, err := build.ImportDir(dirpath, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, filename := range bpkg.GoFiles {
+		file, err := parser.ParseFile(me.fset, filepath.Join(dirpath, filename), nil, parser.AllErrors)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	conf := types.Config{Importer: me}
+	if pkg, err = conf.Check(imppath, me.fset, files, nil); err == nil {
+		me.pkgs[imppath] = pkg
+	}
+	return
+}
+
+func goldenCompare(t *testing.T, wantdirpath string, got map[string][]byte) {
+	for relpath, gotbytes := range got {
+		if wantbytes, err := ioutil.ReadFile(filepath.Join(wantdirpath, relpath+goldenFileSuffix)); os.IsNotExist(err) {
+			t.Errorf("unexpected output %s (re-run with -update if intended)", relpath)
+		} else if err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(gotbytes, wantbytes) {
+			t.Errorf("output %s differs from %s (re-run with -update if intended):\n%s", relpath, filepath.Join(wantdirpath, relpath+goldenFileSuffix), goldenDiff(string(wantbytes), string(gotbytes)))
+		}
+	}
+	ufs.WalkAllFiles(wantdirpath, func(filepath string) bool {
+		if relpath := strings.TrimSuffix(strings.TrimLeft(filepath[len(wantdirpath):], "\\/"), goldenFileSuffix); got[relpath] == nil {
+			t.Errorf("missing output %s (re-run with -update if intended)", relpath)
+		}
+		return true
+	})
+}
+
+func goldenWriteWant(t *testing.T, wantdirpath string, got map[string][]byte) {
+	if err := os.RemoveAll(wantdirpath); err != nil {
+		t.Fatal(err)
+	}
+	for relpath, gotbytes := range got {
+		benchWriteFile(t, filepath.Join(wantdirpath, relpath+goldenFileSuffix), gotbytes)
+	}
+}
+
+// goldenDiff reports just the first differing line rather than a full diff, which `git diff` after an -update run does better anyway
+func goldenDiff(want string, got string) string {
+	wantlines, gotlines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantlines) || i < len(gotlines); i++ {
+		var wantline, gotline string
+		if i < len(wantlines) {
+			wantline = wantlines[i]
+		}
+		if i < len(gotlines) {
+			gotline = gotlines[i]
+		}
+		if wantline != gotline || i >= len(wantlines) || i >= len(gotlines) {
+			return fmt.Sprintf("line %d:\n\twant: %s\n\t got: %s", i+1, wantline, gotline)
+		}
+	}
+	return ""
+}
+
+func goldenCopyDir(t testing.TB, srcdirpath string, dstdirpath string) {
+	ufs.WalkAllFiles(srcdirpath, func(srcfilepath string) bool {
+		benchWriteFile(t, filepath.Join(dstdirpath, srcfilepath[len(srcdirpath):]), goldenReadFile(t, srcfilepath))
+		return true
+	})
+}
+
+func goldenReadFile(t testing.TB, filepath string) []byte {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
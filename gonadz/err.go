@@ -0,0 +1,114 @@
+package 𝒈
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+/*
+Error is what PureScript code throws: all `throw`s in generated code
+(and all crashes in the default FFI packages) panic with an *Error or,
+for failed pattern matches, a *PatternMatchFailure. *Error is also the
+Go type for Effect.Exception's Error. Go code calling into PS code can
+recover from such failures via Try, which turns either into an *Error.
+*/
+type Error struct {
+	Msg   Str
+	Name  Str    // as in JS, "Error" by default
+	Stack string // the Go stack trace as of the creation of the Error
+	Cause error  // the non-Error value thrown or recovered, if any
+}
+
+// Err creates an *Error with the given message, as does JS's `new Error(msg)`.
+func Err(msg Str) *Error {
+	return &Error{Msg: msg, Name: S("Error"), Stack: string(debug.Stack())}
+}
+
+// Errf creates an *Error with the fmt.Sprintf-formatted message.
+func Errf(format string, args ...interface{}) *Error {
+	return Err(S(fmt.Sprintf(format, args...)))
+}
+
+// ErrOf turns any thrown or recovered value into an *Error (or returns nil for nil).
+func ErrOf(v interface{}) *Error {
+	switch e := v.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return e
+	case *PatternMatchFailure: // keeps its Stack, and stays reachable via errors.As
+		err := Err(S(e.Error()))
+		err.Stack, err.Cause = e.Stack, e
+		return err
+	case Str:
+		return Err(e)
+	case error:
+		err := Errf("%v", e)
+		err.Cause = e
+		return err
+	}
+	err := Errf("%v", v)
+	err.Cause = fmt.Errorf("%v", v)
+	return err
+}
+
+// Error implements the error interface: the same "Name: Msg" as JS's Error.prototype.toString.
+func (me *Error) Error() string {
+	if me.Name == "" {
+		return GoStr(me.Msg)
+	}
+	return GoStr(me.Name) + ": " + GoStr(me.Msg)
+}
+
+// Unwrap exposes Cause to errors.Is and errors.As.
+func (me *Error) Unwrap() error { return me.Cause }
+
+// Throw panics with ErrOf(v), for places that cannot `panic(ErrOf(v))` directly.
+func Throw(v interface{}) 𝑻 { panic(ErrOf(v)) }
+
+/*
+Try runs f and recovers from any panic it raises, returning that
+as an *Error. Runtime panics (nil derefs, failed type assertions
+and such) are recovered too, with the runtime.Error as the Cause.
+*/
+func Try(f func() 𝑻) (result 𝑻, err *Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ErrOf(r)
+		}
+	}()
+	result = f()
+	return
+}
+
+/*
+PatternMatchFailure is what generated code panics with where
+a non-exhaustive PureScript `case` (or a partial function)
+meets a value it has no alternative for.
+*/
+type PatternMatchFailure struct {
+	Module    string // the PureScript module's qualified name
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Values    []𝑻    // the scrutinees that failed to match
+	Stack     string // the Go stack trace as of the failure
+}
+
+// PatternMatchFail is called by generated code, see PatternMatchFailure.
+func PatternMatchFail(module string, line int, column int, endline int, endcolumn int, values ...𝑻) *PatternMatchFailure {
+	return &PatternMatchFailure{Module: module, Line: line, Column: column, EndLine: endline, EndColumn: endcolumn, Values: values, Stack: string(debug.Stack())}
+}
+
+// Error implements the error interface with the same message as the JS backend, plus the Values' Go types and values.
+func (me *PatternMatchFailure) Error() string {
+	msg := fmt.Sprintf("Failed pattern match at %s (line %d, column %d - line %d, column %d):", me.Module, me.Line, me.Column, me.EndLine, me.EndColumn)
+	for i, v := range me.Values {
+		if i > 0 {
+			msg += ","
+		}
+		msg += fmt.Sprintf(" ‹%T› %v", v, v)
+	}
+	return msg
+}
@@ -0,0 +1,17 @@
+package 𝙜ˈControlˈApply
+
+import (
+	"github.com/gonadz/g"
+)
+
+func ArrayApply(fs []𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(xs []𝒈.𝑻) []𝒈.𝑻 {
+		result := make([]𝒈.𝑻, 0, len(fs)*len(xs))
+		for _, f := range fs {
+			for _, x := range xs {
+				result = append(result, f.(func(𝒈.𝑻) 𝒈.𝑻)(x))
+			}
+		}
+		return result
+	}
+}
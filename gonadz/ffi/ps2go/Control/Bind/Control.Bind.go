@@ -0,0 +1,14 @@
+package 𝙜ˈControlˈBind
+
+import (
+	"github.com/gonadz/g"
+)
+
+func ArrayBind(arr []𝒈.𝑻) func(func(𝒈.𝑻) []𝒈.𝑻) []𝒈.𝑻 {
+	return func(f func(𝒈.𝑻) []𝒈.𝑻) (result []𝒈.𝑻) {
+		for _, x := range arr {
+			result = append(result, f(x)...)
+		}
+		return
+	}
+}
@@ -0,0 +1,96 @@
+package 𝙜ˈControlˈMonadˈSTˈInternal
+
+import (
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Data/Unit"
+)
+
+// ST is a local-state computation, represented just like an Effect.
+type ST func() 𝒈.𝑻
+
+type STRef *struct{ value 𝒈.𝑻 }
+
+func Map_(f func(𝒈.𝑻) 𝒈.𝑻) func(ST) ST {
+	return func(a ST) ST {
+		return func() 𝒈.𝑻 { return f(a()) }
+	}
+}
+
+func Pure_(a 𝒈.𝑻) ST {
+	return func() 𝒈.𝑻 { return a }
+}
+
+func Bind_(a ST) func(func(𝒈.𝑻) ST) ST {
+	return func(f func(𝒈.𝑻) ST) ST {
+		return func() 𝒈.𝑻 { return f(a())() }
+	}
+}
+
+func Run(f ST) 𝒈.𝑻 {
+	return f()
+}
+
+func While(f ST) func(ST) ST {
+	return func(a ST) ST {
+		return func() 𝒈.𝑻 {
+			for f().(bool) {
+				a()
+			}
+			return 𝙜ˈDataˈUnit.Unitˆ
+		}
+	}
+}
+
+func For(lo int32) func(int32) func(func(int32) ST) ST {
+	return func(hi int32) func(func(int32) ST) ST {
+		return func(f func(int32) ST) ST {
+			return func() 𝒈.𝑻 {
+				for i := lo; i < hi; i++ {
+					f(i)()
+				}
+				return 𝙜ˈDataˈUnit.Unitˆ
+			}
+		}
+	}
+}
+
+func Foreach(as []𝒈.𝑻) func(func(𝒈.𝑻) ST) ST {
+	return func(f func(𝒈.𝑻) ST) ST {
+		return func() 𝒈.𝑻 {
+			for _, a := range as {
+				f(a)()
+			}
+			return 𝙜ˈDataˈUnit.Unitˆ
+		}
+	}
+}
+
+func New(val 𝒈.𝑻) ST {
+	return func() 𝒈.𝑻 { return STRef(&struct{ value 𝒈.𝑻 }{val}) }
+}
+
+func Read(ref STRef) ST {
+	return func() 𝒈.𝑻 { return ref.value }
+}
+
+func Modifyˈ(f func(𝒈.𝑻) struct {
+	State 𝒈.𝑻
+	Value 𝒈.𝑻
+}) func(STRef) ST {
+	return func(ref STRef) ST {
+		return func() 𝒈.𝑻 {
+			t := f(ref.value)
+			ref.value = t.State
+			return t.Value
+		}
+	}
+}
+
+func Write(val 𝒈.𝑻) func(STRef) ST {
+	return func(ref STRef) ST {
+		return func() 𝒈.𝑻 {
+			ref.value = val
+			return val
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package 𝙜ˈDataˈArray
+
+import (
+	"sort"
+
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Data/Unit"
+)
+
+/*
+Maybe lives in the gonad-generated Data.Maybe package: as
+with the JS FFI, callers pass in `Just` and `Nothing`.
+*/
+
+func Range(start int32) func(int32) []int32 {
+	return func(end int32) []int32 {
+		step := int32(1)
+		if start > end {
+			step = -1
+		}
+		result := make([]int32, 0, step*(end-start)+1)
+		for i := start; i != end; i += step {
+			result = append(result, i)
+		}
+		return append(result, end)
+	}
+}
+
+func Replicate(count int32) func(𝒈.𝑻) []𝒈.𝑻 {
+	return func(value 𝒈.𝑻) []𝒈.𝑻 {
+		if count < 1 {
+			return []𝒈.𝑻{}
+		}
+		result := make([]𝒈.𝑻, count)
+		for i := range result {
+			result[i] = value
+		}
+		return result
+	}
+}
+
+func FromFoldableImpl(foldr func(func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) []𝒈.𝑻 {
+	type cons struct {
+		head 𝒈.𝑻
+		tail *cons
+	}
+	return func(xs 𝒈.𝑻) (result []𝒈.𝑻) {
+		list, _ := foldr(func(head 𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻 {
+			return func(tail 𝒈.𝑻) 𝒈.𝑻 { return &cons{head, tail.(*cons)} }
+		})((*cons)(nil))(xs).(*cons)
+		for result = []𝒈.𝑻{}; list != nil; list = list.tail {
+			result = append(result, list.head)
+		}
+		return
+	}
+}
+
+func Length(xs []𝒈.𝑻) int32 {
+	return int32(len(xs))
+}
+
+func Cons(e 𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(l []𝒈.𝑻) []𝒈.𝑻 { return append([]𝒈.𝑻{e}, l...) }
+}
+
+func Snoc(l []𝒈.𝑻) func(𝒈.𝑻) []𝒈.𝑻 {
+	return func(e 𝒈.𝑻) []𝒈.𝑻 { return append(append(make([]𝒈.𝑻, 0, len(l)+1), l...), e) }
+}
+
+func Unconsˈ(empty func(𝒈.𝑻) 𝒈.𝑻) func(func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(next func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(xs []𝒈.𝑻) 𝒈.𝑻 {
+			if len(xs) == 0 {
+				return empty(𝙜ˈDataˈUnit.Unitˆ)
+			}
+			return next(xs[0])(xs[1:])
+		}
+	}
+}
+
+func IndexImpl(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func([]𝒈.𝑻) func(int32) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func([]𝒈.𝑻) func(int32) 𝒈.𝑻 {
+		return func(xs []𝒈.𝑻) func(int32) 𝒈.𝑻 {
+			return func(i int32) 𝒈.𝑻 {
+				if i < 0 || i >= int32(len(xs)) {
+					return nothing
+				}
+				return just(xs[i])
+			}
+		}
+	}
+}
+
+func findIndexImpl(fromEnd bool) func(func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(func(𝒈.𝑻) bool) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(func(𝒈.𝑻) bool) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(nothing 𝒈.𝑻) func(func(𝒈.𝑻) bool) func([]𝒈.𝑻) 𝒈.𝑻 {
+			return func(f func(𝒈.𝑻) bool) func([]𝒈.𝑻) 𝒈.𝑻 {
+				return func(xs []𝒈.𝑻) 𝒈.𝑻 {
+					for i := range xs {
+						if fromEnd {
+							i = len(xs) - 1 - i
+						}
+						if f(xs[i]) {
+							return just(int32(i))
+						}
+					}
+					return nothing
+				}
+			}
+		}
+	}
+}
+
+var (
+	FindIndexImpl     = findIndexImpl(false)
+	FindLastIndexImpl = findIndexImpl(true)
+)
+
+func ƱinsertAt(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(i int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+			return func(a 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+				return func(l []𝒈.𝑻) 𝒈.𝑻 {
+					if i < 0 || i > int32(len(l)) {
+						return nothing
+					}
+					return just(append(append(append(make([]𝒈.𝑻, 0, len(l)+1), l[:i]...), a), l[i:]...))
+				}
+			}
+		}
+	}
+}
+
+func ƱdeleteAt(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(int32) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(int32) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(i int32) func([]𝒈.𝑻) 𝒈.𝑻 {
+			return func(l []𝒈.𝑻) 𝒈.𝑻 {
+				if i < 0 || i >= int32(len(l)) {
+					return nothing
+				}
+				return just(append(append(make([]𝒈.𝑻, 0, len(l)-1), l[:i]...), l[i+1:]...))
+			}
+		}
+	}
+}
+
+func ƱupdateAt(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(i int32) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+			return func(a 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+				return func(l []𝒈.𝑻) 𝒈.𝑻 {
+					if i < 0 || i >= int32(len(l)) {
+						return nothing
+					}
+					l1 := append(make([]𝒈.𝑻, 0, len(l)), l...)
+					l1[i] = a
+					return just(l1)
+				}
+			}
+		}
+	}
+}
+
+func Reverse(l []𝒈.𝑻) []𝒈.𝑻 {
+	result := make([]𝒈.𝑻, len(l))
+	for i, x := range l {
+		result[len(l)-1-i] = x
+	}
+	return result
+}
+
+func Concat(xss [][]𝒈.𝑻) (result []𝒈.𝑻) {
+	result = []𝒈.𝑻{}
+	for _, xs := range xss {
+		result = append(result, xs...)
+	}
+	return
+}
+
+func Filter(f func(𝒈.𝑻) bool) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(xs []𝒈.𝑻) (result []𝒈.𝑻) {
+		result = []𝒈.𝑻{}
+		for _, x := range xs {
+			if f(x) {
+				result = append(result, x)
+			}
+		}
+		return
+	}
+}
+
+func Partition(f func(𝒈.𝑻) bool) func([]𝒈.𝑻) struct {
+	No  []𝒈.𝑻
+	Yes []𝒈.𝑻
+} {
+	return func(xs []𝒈.𝑻) (result struct {
+		No  []𝒈.𝑻
+		Yes []𝒈.𝑻
+	}) {
+		result.No, result.Yes = []𝒈.𝑻{}, []𝒈.𝑻{}
+		for _, x := range xs {
+			if f(x) {
+				result.Yes = append(result.Yes, x)
+			} else {
+				result.No = append(result.No, x)
+			}
+		}
+		return
+	}
+}
+
+func SortImpl(f func(𝒈.𝑻) func(𝒈.𝑻) int32) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(l []𝒈.𝑻) []𝒈.𝑻 {
+		result := append(make([]𝒈.𝑻, 0, len(l)), l...)
+		sort.SliceStable(result, func(i, j int) bool { return f(result[i])(result[j]) < 0 })
+		return result
+	}
+}
+
+// clamps like JS's Array.prototype.slice does
+func sliceBounds(s int32, e int32, l int32) (int32, int32) {
+	if s < 0 {
+		if s += l; s < 0 {
+			s = 0
+		}
+	} else if s > l {
+		s = l
+	}
+	if e < 0 {
+		if e += l; e < 0 {
+			e = 0
+		}
+	} else if e > l {
+		e = l
+	}
+	if e < s {
+		e = s
+	}
+	return s, e
+}
+
+func Slice(s int32) func(int32) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(e int32) func([]𝒈.𝑻) []𝒈.𝑻 {
+		return func(l []𝒈.𝑻) []𝒈.𝑻 {
+			s, e := sliceBounds(s, e, int32(len(l)))
+			return append([]𝒈.𝑻{}, l[s:e]...)
+		}
+	}
+}
+
+func Take(n int32) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(l []𝒈.𝑻) []𝒈.𝑻 {
+		if n < 1 {
+			return []𝒈.𝑻{}
+		}
+		return Slice(0)(n)(l)
+	}
+}
+
+func Drop(n int32) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(l []𝒈.𝑻) []𝒈.𝑻 {
+		if n < 1 {
+			return l
+		}
+		return Slice(n)(int32(len(l)))(l)
+	}
+}
+
+func ZipWith(f func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func([]𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(xs []𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+		return func(ys []𝒈.𝑻) []𝒈.𝑻 {
+			l := len(xs)
+			if len(ys) < l {
+				l = len(ys)
+			}
+			result := make([]𝒈.𝑻, l)
+			for i := range result {
+				result[i] = f(xs[i])(ys[i])
+			}
+			return result
+		}
+	}
+}
+
+func UnsafeIndexImpl(xs []𝒈.𝑻) func(int32) 𝒈.𝑻 {
+	return func(n int32) 𝒈.𝑻 { return xs[n] }
+}
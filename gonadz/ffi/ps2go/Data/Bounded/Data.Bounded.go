@@ -0,0 +1,18 @@
+package 𝙜ˈDataˈBounded
+
+import (
+	"math"
+
+	"github.com/gonadz/g"
+)
+
+var (
+	TopInt    int32 = math.MaxInt32
+	BottomInt int32 = math.MinInt32
+
+	TopChar    𝒈.Char = 0xFFFF
+	BottomChar 𝒈.Char = 0
+
+	TopNumber    = math.Inf(1)
+	BottomNumber = math.Inf(-1)
+)
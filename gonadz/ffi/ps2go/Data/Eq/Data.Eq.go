@@ -0,0 +1,41 @@
+package 𝙜ˈDataˈEq
+
+import (
+	"github.com/gonadz/g"
+)
+
+func EqBooleanImpl(r1 bool) func(bool) bool {
+	return func(r2 bool) bool { return r1 == r2 }
+}
+
+func EqIntImpl(r1 int32) func(int32) bool {
+	return func(r2 int32) bool { return r1 == r2 }
+}
+
+func EqNumberImpl(r1 float64) func(float64) bool {
+	return func(r2 float64) bool { return r1 == r2 }
+}
+
+func EqCharImpl(r1 𝒈.Char) func(𝒈.Char) bool {
+	return func(r2 𝒈.Char) bool { return r1 == r2 }
+}
+
+func EqStringImpl(r1 𝒈.Str) func(𝒈.Str) bool {
+	return func(r2 𝒈.Str) bool { return r1 == r2 }
+}
+
+func EqArrayImpl(f func(𝒈.𝑻) func(𝒈.𝑻) bool) func([]𝒈.𝑻) func([]𝒈.𝑻) bool {
+	return func(xs []𝒈.𝑻) func([]𝒈.𝑻) bool {
+		return func(ys []𝒈.𝑻) bool {
+			if len(xs) != len(ys) {
+				return false
+			}
+			for i := range xs {
+				if !f(xs[i])(ys[i]) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+}
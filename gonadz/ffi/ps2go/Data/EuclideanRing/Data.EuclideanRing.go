@@ -0,0 +1,26 @@
+package 𝙜ˈDataˈEuclideanRing
+
+import (
+	"github.com/gonadz/g"
+)
+
+func IntDegree(x int32) int32 {
+	if x < 0 {
+		if x = -x; x < 0 { // MinInt32
+			x--
+		}
+	}
+	return x
+}
+
+func IntDiv(x int32) func(int32) int32 {
+	return func(y int32) int32 { return 𝒈.IntDiv(x, y) }
+}
+
+func IntMod(x int32) func(int32) int32 {
+	return func(y int32) int32 { return 𝒈.IntMod(x, y) }
+}
+
+func NumDiv(n1 float64) func(float64) float64 {
+	return func(n2 float64) float64 { return n1 / n2 }
+}
@@ -0,0 +1,29 @@
+package 𝙜ˈDataˈFoldable
+
+import (
+	"github.com/gonadz/g"
+)
+
+func FoldrArray(f func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(init 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(xs []𝒈.𝑻) 𝒈.𝑻 {
+			acc := init
+			for i := len(xs) - 1; i >= 0; i-- {
+				acc = f(xs[i])(acc)
+			}
+			return acc
+		}
+	}
+}
+
+func FoldlArray(f func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(init 𝒈.𝑻) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(xs []𝒈.𝑻) 𝒈.𝑻 {
+			acc := init
+			for _, x := range xs {
+				acc = f(acc)(x)
+			}
+			return acc
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package 𝙜ˈDataˈFunctor
+
+import (
+	"github.com/gonadz/g"
+)
+
+func ArrayMap(f func(𝒈.𝑻) 𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(arr []𝒈.𝑻) []𝒈.𝑻 {
+		result := make([]𝒈.𝑻, len(arr))
+		for i, x := range arr {
+			result[i] = f(x)
+		}
+		return result
+	}
+}
@@ -0,0 +1,15 @@
+package 𝙜ˈDataˈFunctorWithIndex
+
+import (
+	"github.com/gonadz/g"
+)
+
+func MapWithIndexArray(f func(int32) func(𝒈.𝑻) 𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(xs []𝒈.𝑻) []𝒈.𝑻 {
+		result := make([]𝒈.𝑻, len(xs))
+		for i, x := range xs {
+			result[i] = f(int32(i))(x)
+		}
+		return result
+	}
+}
@@ -0,0 +1,13 @@
+package 𝙜ˈDataˈHeytingAlgebra
+
+func BoolConj(b1 bool) func(bool) bool {
+	return func(b2 bool) bool { return b1 && b2 }
+}
+
+func BoolDisj(b1 bool) func(bool) bool {
+	return func(b2 bool) bool { return b1 || b2 }
+}
+
+func BoolNot(b bool) bool {
+	return !b
+}
@@ -0,0 +1,33 @@
+package 𝙜ˈDataˈIntˈBits
+
+import (
+	"github.com/gonadz/g"
+)
+
+func And(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return n1 & n2 }
+}
+
+func Or(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return n1 | n2 }
+}
+
+func Xor(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return n1 ^ n2 }
+}
+
+func Shl(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return 𝒈.IntShl(n1, n2) }
+}
+
+func Shr(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return 𝒈.IntShr(n1, n2) }
+}
+
+func Zshr(n1 int32) func(int32) int32 {
+	return func(n2 int32) int32 { return 𝒈.IntZshr(n1, n2) }
+}
+
+func Complement(n int32) int32 {
+	return ^n
+}
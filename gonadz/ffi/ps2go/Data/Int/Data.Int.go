@@ -0,0 +1,73 @@
+package 𝙜ˈDataˈInt
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gonadz/g"
+)
+
+/*
+Radix is a newtype in the gonad-generated Data.Int package:
+we take it as 𝒈.𝑻 and read out the underlying Go int32.
+*/
+
+func radix(newtypeOfInt 𝒈.𝑻) int {
+	return int(reflect.ValueOf(newtypeOfInt).Int())
+}
+
+func FromNumberImpl(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(float64) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(float64) 𝒈.𝑻 {
+		return func(n float64) 𝒈.𝑻 {
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return just(int32(n))
+			}
+			return nothing
+		}
+	}
+}
+
+func ToNumber(n int32) float64 {
+	return float64(n)
+}
+
+func FromStringAsImpl(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+		return func(r 𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+			return func(s 𝒈.Str) 𝒈.𝑻 {
+				if i, err := strconv.ParseInt(strings.TrimPrefix(𝒈.GoStr(s), "+"), radix(r), 32); err == nil {
+					return just(int32(i))
+				}
+				return nothing
+			}
+		}
+	}
+}
+
+func ToStringAs(r 𝒈.𝑻) func(int32) 𝒈.Str {
+	return func(i int32) 𝒈.Str { return 𝒈.S(strconv.FormatInt(int64(i), radix(r))) }
+}
+
+func Quot(x int32) func(int32) int32 {
+	return func(y int32) int32 {
+		if y == 0 {
+			return 0
+		}
+		return x / y // for MinInt32 / -1, Go wraps just like JS
+	}
+}
+
+func Rem(x int32) func(int32) int32 {
+	return func(y int32) int32 {
+		if y == 0 {
+			return 0
+		}
+		return x % y
+	}
+}
+
+func Pow(x int32) func(int32) int32 {
+	return func(y int32) int32 { return 𝒈.IntOfNum(math.Pow(float64(x), float64(y))) }
+}
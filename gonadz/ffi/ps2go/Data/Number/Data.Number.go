@@ -0,0 +1,76 @@
+package 𝙜ˈDataˈNumber
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gonadz/g"
+)
+
+var (
+	Nan      = math.NaN()
+	Infinity = math.Inf(1)
+)
+
+func IsNaN(n float64) bool {
+	return math.IsNaN(n)
+}
+
+func IsFinite(n float64) bool {
+	return !(math.IsNaN(n) || math.IsInf(n, 0))
+}
+
+func FromStringImpl(str 𝒈.Str) func(func(float64) bool) func(func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻 {
+	return func(isFinite func(float64) bool) func(func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻 {
+		return func(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻 {
+			return func(nothing 𝒈.𝑻) 𝒈.𝑻 {
+				if num, err := strconv.ParseFloat(strings.TrimSpace(𝒈.GoStr(str)), 64); err == nil && isFinite(num) {
+					return just(num)
+				}
+				return nothing
+			}
+		}
+	}
+}
+
+func binary(f func(float64, float64) float64) func(float64) func(float64) float64 {
+	return func(n1 float64) func(float64) float64 {
+		return func(n2 float64) float64 { return f(n1, n2) }
+	}
+}
+
+var (
+	Abs   = math.Abs
+	Acos  = math.Acos
+	Asin  = math.Asin
+	Atan  = math.Atan
+	Atan2 = binary(math.Atan2)
+	Ceil  = math.Ceil
+	Cos   = math.Cos
+	Exp   = math.Exp
+	Floor = math.Floor
+	Log   = math.Log
+	Max   = binary(math.Max)
+	Min   = binary(math.Min)
+	Pow   = binary(math.Pow)
+	Sin   = math.Sin
+	Sqrt  = math.Sqrt
+	Tan   = math.Tan
+	Trunc = math.Trunc
+
+	Remainder = binary(math.Mod)
+)
+
+func Round(n float64) float64 {
+	return math.Floor(n + 0.5)
+}
+
+func Sign(n float64) float64 {
+	if n > 0 {
+		return 1
+	} else if n < 0 {
+		return -1
+	}
+	return n
+}
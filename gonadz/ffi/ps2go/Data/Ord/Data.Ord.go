@@ -0,0 +1,88 @@
+package 𝙜ˈDataˈOrd
+
+import (
+	"github.com/gonadz/g"
+)
+
+/*
+The Ordering values (LT, EQ, GT) live in the gonad-generated
+Data.Ordering package, so the caller passes them in and we
+return one of them, just like the JS FFI does.
+*/
+
+func unsafeCompareImpl(lt 𝒈.𝑻, eq 𝒈.𝑻, gt 𝒈.𝑻, less bool, equal bool) 𝒈.𝑻 {
+	if less {
+		return lt
+	} else if equal {
+		return eq
+	}
+	return gt
+}
+
+func OrdBooleanImpl(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(bool) func(bool) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(bool) func(bool) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(bool) func(bool) 𝒈.𝑻 {
+			return func(x bool) func(bool) 𝒈.𝑻 {
+				return func(y bool) 𝒈.𝑻 { return unsafeCompareImpl(lt, eq, gt, y && !x, x == y) }
+			}
+		}
+	}
+}
+
+func OrdIntImpl(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(int32) func(int32) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(int32) func(int32) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(int32) func(int32) 𝒈.𝑻 {
+			return func(x int32) func(int32) 𝒈.𝑻 {
+				return func(y int32) 𝒈.𝑻 { return unsafeCompareImpl(lt, eq, gt, x < y, x == y) }
+			}
+		}
+	}
+}
+
+func OrdNumberImpl(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(float64) func(float64) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(float64) func(float64) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(float64) func(float64) 𝒈.𝑻 {
+			return func(x float64) func(float64) 𝒈.𝑻 {
+				return func(y float64) 𝒈.𝑻 { return unsafeCompareImpl(lt, eq, gt, x < y, x == y) }
+			}
+		}
+	}
+}
+
+func OrdStringImpl(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+			return func(x 𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+				return func(y 𝒈.Str) 𝒈.𝑻 { return unsafeCompareImpl(lt, eq, gt, x < y, x == y) }
+			}
+		}
+	}
+}
+
+func OrdCharImpl(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Char) func(𝒈.Char) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Char) func(𝒈.Char) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(𝒈.Char) func(𝒈.Char) 𝒈.𝑻 {
+			return func(x 𝒈.Char) func(𝒈.Char) 𝒈.𝑻 {
+				return func(y 𝒈.Char) 𝒈.𝑻 { return unsafeCompareImpl(lt, eq, gt, x < y, x == y) }
+			}
+		}
+	}
+}
+
+func OrdArrayImpl(f func(𝒈.𝑻) func(𝒈.𝑻) int32) func([]𝒈.𝑻) func([]𝒈.𝑻) int32 {
+	return func(xs []𝒈.𝑻) func([]𝒈.𝑻) int32 {
+		return func(ys []𝒈.𝑻) int32 {
+			for i := 0; i < len(xs) && i < len(ys); i++ {
+				if o := f(xs[i])(ys[i]); o != 0 {
+					return o
+				}
+			}
+			if len(xs) == len(ys) {
+				return 0
+			} else if len(xs) > len(ys) {
+				return -1
+			}
+			return 1
+		}
+	}
+}
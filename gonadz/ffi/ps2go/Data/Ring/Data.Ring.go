@@ -0,0 +1,9 @@
+package 𝙜ˈDataˈRing
+
+func IntSub(x int32) func(int32) int32 {
+	return func(y int32) int32 { return x - y }
+}
+
+func NumSub(n1 float64) func(float64) float64 {
+	return func(n2 float64) float64 { return n1 - n2 }
+}
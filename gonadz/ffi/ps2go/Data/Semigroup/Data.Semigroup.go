@@ -0,0 +1,20 @@
+package 𝙜ˈDataˈSemigroup
+
+import (
+	"github.com/gonadz/g"
+)
+
+func ConcatString(s1 𝒈.Str) func(𝒈.Str) 𝒈.Str {
+	return func(s2 𝒈.Str) 𝒈.Str { return s1 + s2 }
+}
+
+func ConcatArray(xs []𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(ys []𝒈.𝑻) []𝒈.𝑻 {
+		if len(xs) == 0 {
+			return ys
+		} else if len(ys) == 0 {
+			return xs
+		}
+		return append(append(make([]𝒈.𝑻, 0, len(xs)+len(ys)), xs...), ys...)
+	}
+}
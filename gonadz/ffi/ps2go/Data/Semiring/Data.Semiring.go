@@ -0,0 +1,17 @@
+package 𝙜ˈDataˈSemiring
+
+func IntAdd(x int32) func(int32) int32 {
+	return func(y int32) int32 { return x + y }
+}
+
+func IntMul(x int32) func(int32) int32 {
+	return func(y int32) int32 { return x * y }
+}
+
+func NumAdd(n1 float64) func(float64) float64 {
+	return func(n2 float64) float64 { return n1 + n2 }
+}
+
+func NumMul(n1 float64) func(float64) float64 {
+	return func(n2 float64) float64 { return n1 * n2 }
+}
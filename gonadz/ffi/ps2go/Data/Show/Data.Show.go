@@ -0,0 +1,94 @@
+package 𝙜ˈDataˈShow
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gonadz/g"
+)
+
+func ShowIntImpl(n int32) 𝒈.Str {
+	return 𝒈.S(strconv.Itoa(int(n)))
+}
+
+func ShowNumberImpl(n float64) 𝒈.Str {
+	switch {
+	case math.IsNaN(n):
+		return 𝒈.S("NaN")
+	case math.IsInf(n, 1):
+		return 𝒈.S("Infinity")
+	case math.IsInf(n, -1):
+		return 𝒈.S("-Infinity")
+	}
+	if str := jsNumberToString(n); strings.ContainsAny(str, ".e") {
+		return 𝒈.S(str)
+	} else {
+		return 𝒈.S(str + ".0")
+	}
+}
+
+// formats like JS's Number.prototype.toString: plain decimal unless the exponent is >= 21 or <= -7
+func jsNumberToString(n float64) string {
+	if abs := math.Abs(n); abs == 0 || (abs >= 1e-7 && abs < 1e21) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	str := strconv.FormatFloat(n, 'e', -1, 64)
+	if i := strings.IndexByte(str, 'e'); i > 0 {
+		exp := strings.TrimLeft(str[i+2:], "0")
+		str = str[:i+2] + exp
+	}
+	return str
+}
+
+func ShowCharImpl(c 𝒈.Char) 𝒈.Str {
+	return 𝒈.S("'") + showChars([]𝒈.Char{c}, '\'') + 𝒈.S("'")
+}
+
+func ShowStringImpl(s 𝒈.Str) 𝒈.Str {
+	return 𝒈.S(`"`) + showChars(𝒈.StrChars(s), '"') + 𝒈.S(`"`)
+}
+
+var showEscapes = map[𝒈.Char]string{
+	'\\': `\\`, '\a': `\a`, '\b': `\b`, '\f': `\f`, '\n': `\n`, '\r': `\r`, '\t': `\t`, '\v': `\v`,
+}
+
+// works on Chars rather than Go strings so that lone surrogates (with the "utf16" StringRepr) show just like in JS
+func showChars(cs []𝒈.Char, quote 𝒈.Char) 𝒈.Str {
+	result := make([]𝒈.Char, 0, len(cs))
+	for i, c := range cs {
+		if esc, ok := showEscapes[c]; ok {
+			result = append(result, 𝒈.StrChars(𝒈.S(esc))...)
+		} else if c == quote {
+			result = append(result, '\\', c)
+		} else if c < 0x20 || c == 0x7F {
+			result = append(result, 𝒈.StrChars(𝒈.S(`\`+strconv.Itoa(int(c))))...)
+			if i+1 < len(cs) && cs[i+1] >= '0' && cs[i+1] <= '9' {
+				result = append(result, '\\', '&')
+			}
+		} else {
+			result = append(result, c)
+		}
+	}
+	return 𝒈.StrFromChars(result)
+}
+
+func ShowArrayImpl(f func(𝒈.𝑻) 𝒈.Str) func([]𝒈.𝑻) 𝒈.Str {
+	return func(xs []𝒈.𝑻) 𝒈.Str {
+		ss := make([]𝒈.Str, len(xs))
+		for i, x := range xs {
+			ss[i] = f(x)
+		}
+		return 𝒈.S("[") + 𝒈.StrJoin(ss, 𝒈.S(",")) + 𝒈.S("]")
+	}
+}
+
+func Cons(head 𝒈.𝑻) func([]𝒈.𝑻) []𝒈.𝑻 {
+	return func(tail []𝒈.𝑻) []𝒈.𝑻 {
+		return append([]𝒈.𝑻{head}, tail...)
+	}
+}
+
+func Join(separator 𝒈.Str) func([]𝒈.Str) 𝒈.Str {
+	return func(xs []𝒈.Str) 𝒈.Str { return 𝒈.StrJoin(xs, separator) }
+}
@@ -0,0 +1,175 @@
+package 𝙜ˈDataˈStringˈCodeUnits
+
+import (
+	"github.com/gonadz/g"
+)
+
+/*
+A "code unit" here is whatever a 𝒈.Char is: a UTF-16 code unit
+as in the JS FFI with the "utf16" StringRepr, but a rune with the
+default "utf8" one (see str.go in the root 𝒈 package).
+*/
+
+func FromCharArray(a []𝒈.Char) 𝒈.Str {
+	return 𝒈.StrFromChars(a)
+}
+
+func ToCharArray(s 𝒈.Str) []𝒈.Char {
+	return 𝒈.StrChars(s)
+}
+
+func Singleton(c 𝒈.Char) 𝒈.Str {
+	return 𝒈.StrOfChar(c)
+}
+
+func ƱcharAt(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+		return func(i int32) func(𝒈.Str) 𝒈.𝑻 {
+			return func(s 𝒈.Str) 𝒈.𝑻 {
+				if i >= 0 && int(i) < 𝒈.StrLen(s) {
+					return just(𝒈.StrCharAt(s, int(i)))
+				}
+				return nothing
+			}
+		}
+	}
+}
+
+func ƱtoChar(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+		return func(s 𝒈.Str) 𝒈.𝑻 {
+			if 𝒈.StrLen(s) == 1 {
+				return just(𝒈.StrCharAt(s, 0))
+			}
+			return nothing
+		}
+	}
+}
+
+func Length(s 𝒈.Str) int32 {
+	return int32(𝒈.StrLen(s))
+}
+
+func CountPrefix(p func(𝒈.Char) bool) func(𝒈.Str) int32 {
+	return func(s 𝒈.Str) (i int32) {
+		for _, c := range 𝒈.StrChars(s) {
+			if !p(c) {
+				break
+			}
+			i++
+		}
+		return
+	}
+}
+
+// clamps like JS's String.prototype.substring does
+func clamp(s 𝒈.Str, i int32) int {
+	if i < 0 {
+		return 0
+	} else if l := 𝒈.StrLen(s); int(i) > l {
+		return l
+	}
+	return int(i)
+}
+
+func ƱindexOf(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+		return func(x 𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+			return func(s 𝒈.Str) 𝒈.𝑻 {
+				if i := 𝒈.StrIndex(s, x); i >= 0 {
+					return just(int32(i))
+				}
+				return nothing
+			}
+		}
+	}
+}
+
+func ƱindexOfˈ(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+		return func(x 𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+			return func(startAt int32) func(𝒈.Str) 𝒈.𝑻 {
+				return func(s 𝒈.Str) 𝒈.𝑻 {
+					if startAt < 0 || int(startAt) > 𝒈.StrLen(s) {
+						return nothing
+					}
+					if i := 𝒈.StrIndex(𝒈.StrSlice(s, int(startAt), 𝒈.StrLen(s)), x); i >= 0 {
+						return just(startAt + int32(i))
+					}
+					return nothing
+				}
+			}
+		}
+	}
+}
+
+func ƱlastIndexOf(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+		return func(x 𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+			return func(s 𝒈.Str) 𝒈.𝑻 {
+				if i := 𝒈.StrLastIndex(s, x); i >= 0 {
+					return just(int32(i))
+				}
+				return nothing
+			}
+		}
+	}
+}
+
+func ƱlastIndexOfˈ(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+		return func(x 𝒈.Str) func(int32) func(𝒈.Str) 𝒈.𝑻 {
+			return func(startAt int32) func(𝒈.Str) 𝒈.𝑻 {
+				return func(s 𝒈.Str) 𝒈.𝑻 {
+					if startAt < 0 || int(startAt) > 𝒈.StrLen(s) {
+						return nothing
+					}
+					if i := 𝒈.StrLastIndex(𝒈.StrSlice(s, 0, clamp(s, startAt+int32(𝒈.StrLen(x)))), x); i >= 0 {
+						return just(int32(i))
+					}
+					return nothing
+				}
+			}
+		}
+	}
+}
+
+func Take(n int32) func(𝒈.Str) 𝒈.Str {
+	return func(s 𝒈.Str) 𝒈.Str { return 𝒈.StrSlice(s, 0, clamp(s, n)) }
+}
+
+func Drop(n int32) func(𝒈.Str) 𝒈.Str {
+	return func(s 𝒈.Str) 𝒈.Str { return 𝒈.StrSlice(s, clamp(s, n), 𝒈.StrLen(s)) }
+}
+
+func Ʊslice(b int32) func(int32) func(𝒈.Str) 𝒈.Str {
+	return func(e int32) func(𝒈.Str) 𝒈.Str {
+		return func(s 𝒈.Str) 𝒈.Str {
+			bi, ei, l := b, e, int32(𝒈.StrLen(s))
+			if bi < 0 { // negative indices count from the end, like JS's String.prototype.slice
+				bi += l
+			}
+			if ei < 0 {
+				ei += l
+			}
+			if bi, ei := clamp(s, bi), clamp(s, ei); bi < ei {
+				return 𝒈.StrSlice(s, bi, ei)
+			}
+			return 𝒈.StrSlice(s, 0, 0)
+		}
+	}
+}
+
+func SplitAt(i int32) func(𝒈.Str) struct {
+	After  𝒈.Str
+	Before 𝒈.Str
+} {
+	return func(s 𝒈.Str) (result struct {
+		After  𝒈.Str
+		Before 𝒈.Str
+	}) {
+		b := clamp(s, i)
+		result.Before, result.After = 𝒈.StrSlice(s, 0, b), 𝒈.StrSlice(s, b, 𝒈.StrLen(s))
+		return
+	}
+}
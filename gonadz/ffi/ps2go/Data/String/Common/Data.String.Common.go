@@ -0,0 +1,67 @@
+package 𝙜ˈDataˈStringˈCommon
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gonadz/g"
+)
+
+/*
+Pattern and Replacement are newtypes in the gonad-generated
+Data.String.Pattern package: we take them as 𝒈.𝑻 and read
+out the underlying 𝒈.Str.
+*/
+
+func str(newtypeOfString 𝒈.𝑻) 𝒈.Str {
+	return 𝒈.Str(reflect.ValueOf(newtypeOfString).String())
+}
+
+func ƱlocaleCompare(lt 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+	return func(eq 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+		return func(gt 𝒈.𝑻) func(𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+			return func(s1 𝒈.Str) func(𝒈.Str) 𝒈.𝑻 {
+				return func(s2 𝒈.Str) 𝒈.𝑻 {
+					if s1 < s2 {
+						return lt
+					} else if s1 > s2 {
+						return gt
+					}
+					return eq
+				}
+			}
+		}
+	}
+}
+
+func Replace(s1 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) 𝒈.Str {
+	return func(s2 𝒈.𝑻) func(𝒈.Str) 𝒈.Str {
+		return func(s3 𝒈.Str) 𝒈.Str { return 𝒈.StrReplace(s3, str(s1), str(s2), 1) }
+	}
+}
+
+func ReplaceAll(s1 𝒈.𝑻) func(𝒈.𝑻) func(𝒈.Str) 𝒈.Str {
+	return func(s2 𝒈.𝑻) func(𝒈.Str) 𝒈.Str {
+		return func(s3 𝒈.Str) 𝒈.Str { return 𝒈.StrReplace(s3, str(s1), str(s2), -1) }
+	}
+}
+
+func Split(sep 𝒈.𝑻) func(𝒈.Str) []𝒈.Str {
+	return func(s 𝒈.Str) []𝒈.Str { return 𝒈.StrSplit(s, str(sep)) }
+}
+
+func ToLower(s 𝒈.Str) 𝒈.Str {
+	return 𝒈.S(strings.ToLower(𝒈.GoStr(s)))
+}
+
+func ToUpper(s 𝒈.Str) 𝒈.Str {
+	return 𝒈.S(strings.ToUpper(𝒈.GoStr(s)))
+}
+
+func Trim(s 𝒈.Str) 𝒈.Str {
+	return 𝒈.S(strings.TrimSpace(𝒈.GoStr(s)))
+}
+
+func JoinWith(s 𝒈.Str) func([]𝒈.Str) 𝒈.Str {
+	return func(xs []𝒈.Str) 𝒈.Str { return 𝒈.StrJoin(xs, s) }
+}
@@ -0,0 +1,21 @@
+package 𝙜ˈDataˈStringˈUnsafe
+
+import (
+	"github.com/gonadz/g"
+)
+
+func CharAt(i int32) func(𝒈.Str) 𝒈.Char {
+	return func(s 𝒈.Str) 𝒈.Char {
+		if i >= 0 && int(i) < 𝒈.StrLen(s) {
+			return 𝒈.StrCharAt(s, int(i))
+		}
+		panic(𝒈.Err(𝒈.S("Data.String.Unsafe.charAt: Invalid index.")))
+	}
+}
+
+func Char(s 𝒈.Str) 𝒈.Char {
+	if 𝒈.StrLen(s) == 1 {
+		return 𝒈.StrCharAt(s, 0)
+	}
+	panic(𝒈.Err(𝒈.S("Data.String.Unsafe.char: Expected string of length 1.")))
+}
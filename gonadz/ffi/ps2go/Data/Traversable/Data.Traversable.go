@@ -0,0 +1,33 @@
+package 𝙜ˈDataˈTraversable
+
+import (
+	"github.com/gonadz/g"
+)
+
+/*
+The applicative `m` is arbitrary, so `apply`, `map` and `pure`
+come in as funcs over 𝒈.𝑻 (just as the JS FFI has it).
+*/
+
+type fn = func(𝒈.𝑻) 𝒈.𝑻
+
+func TraverseArrayImpl(apply func(𝒈.𝑻) func(𝒈.𝑻) 𝒈.𝑻) func(func(fn) func(𝒈.𝑻) 𝒈.𝑻) func(func(𝒈.𝑻) 𝒈.𝑻) func(fn) func([]𝒈.𝑻) 𝒈.𝑻 {
+	return func(fmap func(fn) func(𝒈.𝑻) 𝒈.𝑻) func(func(𝒈.𝑻) 𝒈.𝑻) func(fn) func([]𝒈.𝑻) 𝒈.𝑻 {
+		return func(pure func(𝒈.𝑻) 𝒈.𝑻) func(fn) func([]𝒈.𝑻) 𝒈.𝑻 {
+			return func(f fn) func([]𝒈.𝑻) 𝒈.𝑻 {
+				snoc := func(xs 𝒈.𝑻) 𝒈.𝑻 {
+					return fn(func(x 𝒈.𝑻) 𝒈.𝑻 {
+						return append(append(make([]𝒈.𝑻, 0, len(xs.([]𝒈.𝑻))+1), xs.([]𝒈.𝑻)...), x)
+					})
+				}
+				return func(array []𝒈.𝑻) 𝒈.𝑻 {
+					acc := pure([]𝒈.𝑻{})
+					for _, x := range array {
+						acc = apply(fmap(snoc)(acc))(f(x))
+					}
+					return acc
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package 𝙜ˈDataˈUnit
+
+type Unit struct{}
+
+// the `unit` value: the ˆ suffix is how gonad disambiguates a value named like a type, see prepMiscFixups
+var Unitˆ Unit
@@ -0,0 +1,34 @@
+package 𝙜ˈEffectˈConsole
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Data/Unit"
+	"github.com/gonadz/g/ffi/ps2go/Effect"
+)
+
+func logTo(w io.Writer, s 𝒈.Str) 𝙜ˈEffect.Effect {
+	return func() 𝒈.𝑻 {
+		fmt.Fprintln(w, 𝒈.GoStr(s))
+		return 𝙜ˈDataˈUnit.Unitˆ
+	}
+}
+
+func Log(s 𝒈.Str) 𝙜ˈEffect.Effect {
+	return logTo(os.Stdout, s)
+}
+
+func Info(s 𝒈.Str) 𝙜ˈEffect.Effect {
+	return logTo(os.Stdout, s)
+}
+
+func Warn(s 𝒈.Str) 𝙜ˈEffect.Effect {
+	return logTo(os.Stderr, s)
+}
+
+func Error(s 𝒈.Str) 𝙜ˈEffect.Effect {
+	return logTo(os.Stderr, s)
+}
@@ -0,0 +1,62 @@
+package 𝙜ˈEffect
+
+import (
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Data/Unit"
+)
+
+// Effect is an effectful computation: like in the JS FFI, a nullary func performing it.
+type Effect func() 𝒈.𝑻
+
+func PureE(a 𝒈.𝑻) Effect {
+	return func() 𝒈.𝑻 { return a }
+}
+
+func BindE(a Effect) func(func(𝒈.𝑻) Effect) Effect {
+	return func(f func(𝒈.𝑻) Effect) Effect {
+		return func() 𝒈.𝑻 { return f(a())() }
+	}
+}
+
+func UntilE(f Effect) Effect {
+	return func() 𝒈.𝑻 {
+		for !f().(bool) {
+		}
+		return 𝙜ˈDataˈUnit.Unitˆ
+	}
+}
+
+func WhileE(f Effect) func(Effect) Effect {
+	return func(a Effect) Effect {
+		return func() 𝒈.𝑻 {
+			for f().(bool) {
+				a()
+			}
+			return 𝙜ˈDataˈUnit.Unitˆ
+		}
+	}
+}
+
+func ForE(lo int32) func(int32) func(func(int32) Effect) Effect {
+	return func(hi int32) func(func(int32) Effect) Effect {
+		return func(f func(int32) Effect) Effect {
+			return func() 𝒈.𝑻 {
+				for i := lo; i < hi; i++ {
+					f(i)()
+				}
+				return 𝙜ˈDataˈUnit.Unitˆ
+			}
+		}
+	}
+}
+
+func ForeachE(as []𝒈.𝑻) func(func(𝒈.𝑻) Effect) Effect {
+	return func(f func(𝒈.𝑻) Effect) Effect {
+		return func() 𝒈.𝑻 {
+			for _, a := range as {
+				f(a)()
+			}
+			return 𝙜ˈDataˈUnit.Unitˆ
+		}
+	}
+}
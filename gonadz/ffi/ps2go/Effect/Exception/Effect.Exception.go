@@ -0,0 +1,75 @@
+package 𝙜ˈEffectˈException
+
+import (
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Effect"
+)
+
+// Error is the runtime error type that all PureScript throws panic with, see gonadz/err.go.
+type Error = *𝒈.Error
+
+// like JS's err.stack, which starts with the "Name: Msg" line
+func ShowErrorImpl(err Error) 𝒈.Str {
+	if err.Stack == "" {
+		return 𝒈.S(err.Error())
+	}
+	return 𝒈.S(err.Error() + "\n" + err.Stack)
+}
+
+// the `error` value: the ˆ suffix is how gonad disambiguates a value named like a type, see prepMiscFixups
+func Errorˆ(msg 𝒈.Str) Error {
+	return 𝒈.Err(msg)
+}
+
+func ErrorWithCause(msg 𝒈.Str) func(Error) Error {
+	return func(cause Error) Error {
+		err := 𝒈.Err(msg)
+		err.Cause = cause
+		return err
+	}
+}
+
+func ErrorWithName(msg 𝒈.Str) func(𝒈.Str) Error {
+	return func(name 𝒈.Str) Error {
+		err := 𝒈.Err(msg)
+		err.Name = name
+		return err
+	}
+}
+
+func Message(err Error) 𝒈.Str {
+	return err.Msg
+}
+
+func Name(err Error) 𝒈.Str {
+	return err.Name
+}
+
+// like IndexImpl in Data.Array: callers pass in `Just` and `Nothing`
+func StackImpl(just func(𝒈.𝑻) 𝒈.𝑻) func(𝒈.𝑻) func(Error) 𝒈.𝑻 {
+	return func(nothing 𝒈.𝑻) func(Error) 𝒈.𝑻 {
+		return func(err Error) 𝒈.𝑻 {
+			if err.Stack == "" {
+				return nothing
+			}
+			return just(𝒈.S(err.Stack))
+		}
+	}
+}
+
+func ThrowException(err Error) 𝙜ˈEffect.Effect {
+	return func() 𝒈.𝑻 { panic(err) }
+}
+
+// recovers from all panics raised while running the Effect, not just those from throwException
+func CatchException(c func(Error) 𝙜ˈEffect.Effect) func(𝙜ˈEffect.Effect) 𝙜ˈEffect.Effect {
+	return func(t 𝙜ˈEffect.Effect) 𝙜ˈEffect.Effect {
+		return func() 𝒈.𝑻 {
+			if result, err := 𝒈.Try(t); err != nil {
+				return c(err)()
+			} else {
+				return result
+			}
+		}
+	}
+}
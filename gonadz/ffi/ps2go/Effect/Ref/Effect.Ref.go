@@ -0,0 +1,39 @@
+package 𝙜ˈEffectˈRef
+
+import (
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Data/Unit"
+	"github.com/gonadz/g/ffi/ps2go/Effect"
+)
+
+type Ref *struct{ value 𝒈.𝑻 }
+
+func New(val 𝒈.𝑻) 𝙜ˈEffect.Effect {
+	return func() 𝒈.𝑻 { return Ref(&struct{ value 𝒈.𝑻 }{val}) }
+}
+
+func Read(ref Ref) 𝙜ˈEffect.Effect {
+	return func() 𝒈.𝑻 { return ref.value }
+}
+
+func Modifyˈ(f func(𝒈.𝑻) struct {
+	State 𝒈.𝑻
+	Value 𝒈.𝑻
+}) func(Ref) 𝙜ˈEffect.Effect {
+	return func(ref Ref) 𝙜ˈEffect.Effect {
+		return func() 𝒈.𝑻 {
+			t := f(ref.value)
+			ref.value = t.State
+			return t.Value
+		}
+	}
+}
+
+func Write(val 𝒈.𝑻) func(Ref) 𝙜ˈEffect.Effect {
+	return func(ref Ref) 𝙜ˈEffect.Effect {
+		return func() 𝒈.𝑻 {
+			ref.value = val
+			return 𝙜ˈDataˈUnit.Unitˆ
+		}
+	}
+}
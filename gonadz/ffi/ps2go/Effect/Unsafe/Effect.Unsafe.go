@@ -0,0 +1,10 @@
+package 𝙜ˈEffectˈUnsafe
+
+import (
+	"github.com/gonadz/g"
+	"github.com/gonadz/g/ffi/ps2go/Effect"
+)
+
+func UnsafePerformEffect(f 𝙜ˈEffect.Effect) 𝒈.𝑻 {
+	return f()
+}
@@ -0,0 +1,10 @@
+package 𝙜ˈPartial
+
+import (
+	"github.com/gonadz/g"
+)
+
+// the leading arg is the (empty) Partial dictionary that callers pass along
+func CrashWith(_ 𝒈.𝑻) func(𝒈.Str) 𝒈.𝑻 {
+	return func(msg 𝒈.Str) 𝒈.𝑻 { panic(𝒈.Err(msg)) }
+}
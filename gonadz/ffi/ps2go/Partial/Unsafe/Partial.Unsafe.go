@@ -0,0 +1,16 @@
+package 𝙜ˈPartialˈUnsafe
+
+import (
+	"github.com/gonadz/g"
+)
+
+// f expects the (empty) Partial dictionary
+func UnsafePartial(f 𝒈.𝑻) 𝒈.𝑻 {
+	switch fn := f.(type) {
+	case func(𝒈.𝑻) 𝒈.𝑻:
+		return fn(nil)
+	case func() 𝒈.𝑻:
+		return fn()
+	}
+	return f
+}
@@ -0,0 +1,85 @@
+/*
+Package 𝒈 is the root of gonad's default FFI tree, deployed
+as github.com/gonadz/g into Gonad.Out.GoDirSrcPath by gonad.
+
+All gonad-generated packages and all the default FFI packages
+(in ffi/ps2go, one per PureScript module with foreign imports)
+share the few bits declared in here.
+*/
+package 𝒈
+
+import (
+	"math"
+)
+
+// 𝑻 stands in for all PureScript type variables. It's an alias (not
+// a defined type) so that func signatures using it stay identical to
+// those spelling out interface{} in generated code.
+type 𝑻 = interface{}
+
+/*
+PureScript Int semantics for those operators whose Go
+counterparts differ on int32 (the Go type for Prim.Int):
+generated code calls these instead of `/`, `%`, `<<`, `>>`
+and the `>>>` that Go lacks. (Other int32 arithmetic wraps
+around in Go just like it does in PureScript.)
+*/
+
+// IntDiv is PureScript's Euclidean `div`: the remainder is never
+// negative, and division by zero results in 0 instead of a panic.
+func IntDiv(x, y int32) int32 {
+	if y == 0 {
+		return 0
+	} else if y == -1 {
+		return -x // MinInt32 / -1 wraps to MinInt32 instead of panicking
+	}
+	q := x / y
+	if x%y < 0 {
+		if y > 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// IntMod is PureScript's Euclidean `mod`: never negative, and 0 for y == 0.
+func IntMod(x, y int32) int32 {
+	if y == 0 || y == -1 {
+		return 0
+	}
+	m := x % y
+	if m < 0 {
+		if y > 0 {
+			m += y
+		} else {
+			m -= y
+		}
+	}
+	return m
+}
+
+// IntShl is JavaScript's `<<`: only the lowest 5 bits of the shift count count.
+func IntShl(x, y int32) int32 { return x << (uint32(y) & 31) }
+
+// IntShr is JavaScript's `>>` (sign-propagating).
+func IntShr(x, y int32) int32 { return x >> (uint32(y) & 31) }
+
+// IntZshr is JavaScript's `>>>` (zero-fill), reinterpreted as int32 just like `x >>> y | 0`.
+func IntZshr(x, y int32) int32 { return int32(uint32(x) >> (uint32(y) & 31)) }
+
+// NumMod is JavaScript's `%` on Number operands (Go's `%` being int-only).
+func NumMod(x, y float64) float64 { return math.Mod(x, y) }
+
+// IntOfNum is JavaScript's `n | 0`: truncated, then wrapped around modulo 2^32 (and 0 for NaN or ±Infinity).
+func IntOfNum(n float64) int32 {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0
+	}
+	m := math.Mod(math.Trunc(n), 1<<32)
+	if m < 0 {
+		m += 1 << 32
+	}
+	return int32(uint32(m))
+}
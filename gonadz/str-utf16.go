@@ -0,0 +1,78 @@
+//go:build gonad_utf16
+
+package 𝒈
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// StrRepr is the Gonad.CodeGen.StringRepr setting this package was deployed for.
+const StrRepr = "utf16"
+
+// Str holds UTF-16 code units, 2 bytes each, big-endian: so that
+// Go's ==, <, + on the underlying string behave just like JS's on
+// its UTF-16 strings. (And so that Str literals can be constants.)
+type Str string
+
+// Char is a single UTF-16 code unit.
+type Char = uint16
+
+func S(s string) Str { return StrFromChars(utf16.Encode([]rune(s))) }
+
+func GoStr(s Str) string { return string(utf16.Decode(StrChars(s))) }
+
+// StrLen counts the Chars (UTF-16 code units) in s.
+func StrLen(s Str) int { return len(s) / 2 }
+
+func StrChars(s Str) []Char {
+	cs := make([]Char, len(s)/2)
+	for i := range cs {
+		cs[i] = StrCharAt(s, i)
+	}
+	return cs
+}
+
+func StrFromChars(cs []Char) Str {
+	buf := make([]byte, 2*len(cs))
+	for i, c := range cs {
+		buf[2*i], buf[2*i+1] = byte(c>>8), byte(c)
+	}
+	return Str(buf)
+}
+
+func StrOfChar(c Char) Str { return Str([]byte{byte(c >> 8), byte(c)}) }
+
+// StrCharAt returns the i-th Char in s, i must be in range.
+func StrCharAt(s Str, i int) Char { return Char(s[2*i])<<8 | Char(s[2*i+1]) }
+
+// StrSlice returns the Chars from b (inclusive) to e (exclusive), both must be in range with b <= e.
+func StrSlice(s Str, b int, e int) Str { return s[2*b : 2*e] }
+
+// StrIndex returns the Char index of the first occurrence of sub in s, or -1.
+func StrIndex(s Str, sub Str) int {
+	for offset := 0; offset <= len(s); {
+		i := strings.Index(string(s[offset:]), string(sub))
+		if i < 0 {
+			break
+		} else if i += offset; i%2 == 0 {
+			return i / 2
+		}
+		offset = i + 1 // a match straddling 2 code units doesn't count
+	}
+	return -1
+}
+
+// StrLastIndex returns the Char index of the last occurrence of sub in s, or -1.
+func StrLastIndex(s Str, sub Str) int {
+	for end := len(s); end >= 0; {
+		i := strings.LastIndex(string(s[:end]), string(sub))
+		if i < 0 {
+			break
+		} else if i%2 == 0 {
+			return i / 2
+		}
+		end = i + len(sub) - 1 // a match straddling 2 code units doesn't count
+	}
+	return -1
+}
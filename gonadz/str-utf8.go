@@ -0,0 +1,62 @@
+//go:build !gonad_utf16
+
+package 𝒈
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// StrRepr is the Gonad.CodeGen.StringRepr setting this package was deployed for.
+const StrRepr = "utf8"
+
+type (
+	Str  = string
+	Char = rune
+)
+
+func S(s string) Str { return s }
+
+func GoStr(s Str) string { return s }
+
+// StrLen counts the Chars in s.
+func StrLen(s Str) int { return utf8.RuneCountInString(s) }
+
+func StrChars(s Str) []Char { return []rune(s) }
+
+func StrFromChars(cs []Char) Str { return string(cs) }
+
+func StrOfChar(c Char) Str { return string(c) }
+
+// StrCharAt returns the i-th Char in s, i must be in range.
+func StrCharAt(s Str, i int) Char { return []rune(s)[i] }
+
+// StrSlice returns the Chars from b (inclusive) to e (exclusive), both must be in range with b <= e.
+func StrSlice(s Str, b int, e int) Str { return s[byteIndex(s, b):byteIndex(s, e)] }
+
+// StrIndex returns the Char index of the first occurrence of sub in s, or -1.
+func StrIndex(s Str, sub Str) int {
+	if i := strings.Index(s, sub); i >= 0 {
+		return utf8.RuneCountInString(s[:i])
+	}
+	return -1
+}
+
+// StrLastIndex returns the Char index of the last occurrence of sub in s, or -1.
+func StrLastIndex(s Str, sub Str) int {
+	if i := strings.LastIndex(s, sub); i >= 0 {
+		return utf8.RuneCountInString(s[:i])
+	}
+	return -1
+}
+
+// turns a rune index into a byte index
+func byteIndex(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
@@ -0,0 +1,73 @@
+package 𝒈
+
+/*
+Str and Char are the Go types for PureScript's Prim.String and
+Prim.Char, as per the Gonad.CodeGen.StringRepr setting in bower.json:
+
+"utf8" (the default, see str-utf8.go): Str and Char are mere aliases
+for Go's string and rune. Native and fast, but diverging from the JS
+backend where strings are UTF-16: Data.String.CodeUnits functions
+count runes instead of UTF-16 code units, chars outside the BMP are
+1 Char instead of 2, and lone surrogates cannot be represented.
+
+"utf16" (see str-utf16.go): Str holds UTF-16 code units and Char
+is one such code unit, faithful to the JS backend's semantics.
+
+gonad deploys only the file matching the setting (stripping its
+build constraint), so the default FFI packages are written once
+against the API below and work with either representation.
+Conversions to and from Go strings (S, GoStr) are lossy for lone
+surrogates in "utf16" mode, as Go strings are UTF-8.
+*/
+
+// StrReplace replaces the first n (or all if n < 0) occurrences of old in s with new, like strings.Replace.
+func StrReplace(s Str, old Str, new Str, n int) (result Str) {
+	lold := StrLen(old)
+	if lold == 0 { // new goes in before each Char and at the end
+		cs := StrChars(s)
+		for i := 0; i <= len(cs); i++ {
+			if n < 0 || i < n {
+				result += new
+			}
+			if i < len(cs) {
+				result += StrOfChar(cs[i])
+			}
+		}
+		return
+	}
+	for ; n != 0; n-- {
+		i := StrIndex(s, old)
+		if i < 0 {
+			break
+		}
+		result, s = result+StrSlice(s, 0, i)+new, StrSlice(s, i+lold, StrLen(s))
+	}
+	return result + s
+}
+
+// StrSplit splits s around each occurrence of sep (or into its Chars if sep is empty), like JS's String.prototype.split.
+func StrSplit(s Str, sep Str) (parts []Str) {
+	parts = []Str{}
+	if lsep := StrLen(sep); lsep == 0 {
+		for _, c := range StrChars(s) {
+			parts = append(parts, StrOfChar(c))
+		}
+	} else {
+		for i := StrIndex(s, sep); i >= 0; i = StrIndex(s, sep) {
+			parts, s = append(parts, StrSlice(s, 0, i)), StrSlice(s, i+lsep, StrLen(s))
+		}
+		parts = append(parts, s)
+	}
+	return
+}
+
+// StrJoin concatenates all ss with sep in between, like strings.Join.
+func StrJoin(ss []Str, sep Str) (result Str) {
+	for i, s := range ss {
+		if i > 0 {
+			result += sep
+		}
+		result += s
+	}
+	return
+}
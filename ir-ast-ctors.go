@@ -1,6 +1,8 @@
-package main
+package gonad
 
 import (
+	"strconv"
+
 	"github.com/metaleap/go-util/dev/ps"
 	"github.com/metaleap/go-util/str"
 )
@@ -55,6 +57,7 @@ func ªO(typeref *irANamedTypeRef, fields ...*irALitObjField) *irALitObj {
 
 func ªOFld(fieldval irA) *irALitObjField {
 	a := &irALitObjField{FieldVal: fieldval}
+	a.FieldVal.Base().parent = a // else syms in field values can't resolve what they refer to, eg. `{name: p.name}` would miss that p is a Person with Go field Name
 	return a
 }
 
@@ -121,6 +124,12 @@ func ªFunc() *irAFunc {
 	return a
 }
 
+func ªCtor(f *irAFunc) *irACtor {
+	a := &irACtor{irAFunc: *f}
+	a.FuncImpl.parent = a
+	return a
+}
+
 func ªIf(cond irA) *irAIf {
 	a := &irAIf{If: cond, Then: ªBlock()}
 	a.If.Base().parent, a.Then.parent = a, a
@@ -177,14 +186,35 @@ func ªPanic(errarg irA) *irAPanic {
 	return a
 }
 
+// ªPatMatchFail returns nil unless msg is coreimp's "Failed pattern match at Mod.Name (line 1, column 2 - line 3, column 4): ".
+func ªPatMatchFail(msg string, vals ...irA) *irAPatMatchFail {
+	m := rxPatMatchFailMsg.FindStringSubmatch(msg)
+	if m == nil {
+		return nil
+	}
+	a := &irAPatMatchFail{ModQName: m[1], Scrutinees: vals}
+	for i := range a.SrcPos {
+		a.SrcPos[i], _ = strconv.Atoi(m[i+2])
+	}
+	for _, val := range vals {
+		val.Base().parent = a
+	}
+	return a
+}
+
 func ªPkgSym(pkgname string, symbol string) *irAPkgSym {
+	a := &irAPkgSym{PkgName: pkgname, Symbol: symbol}
+	return a
+}
+
+// pkgSym is ªPkgSym but capitalizes symbol if pkgname is that of a PureScript module, all of whose Go symbols are exported
+func (me *irAst) pkgSym(pkgname string, symbol string) *irAPkgSym {
 	if pkgname != "" {
-		if mod := findModuleByPName(pkgname); mod != nil {
+		if mod := me.mod.findModuleByPName(pkgname); mod != nil {
 			symbol = ustr.Upper.Ensure(symbol, 0)
 		}
 	}
-	a := &irAPkgSym{PkgName: pkgname, Symbol: symbol}
-	return a
+	return ªPkgSym(pkgname, symbol)
 }
 
 func ªRet(retarg irA) *irARet {
@@ -0,0 +1,174 @@
+package gonad
+
+/*
+Golang intermediate-representation AST:
+ordering of top-level initialization. PureScript allows
+top-level values that refer to each other (through funcs
+and lambdas), but Go's package initialization rejects all
+reference cycles among `var`s: those vars (and all others
+that need their values at init time) get declared without
+initializers and are instead assigned in a `func init()`.
+*/
+
+type irATopLevelRefs map[string]map[string]bool
+
+// topLevelDeferredLets returns the top-level lets that must not be initialized in their `var` decls, in the order to assign them in `func init()`.
+func (me *irAst) topLevelDeferredLets() (deferred []*irALet) {
+	defs, lets := map[string]irA{}, []*irALet{}
+	me.walkTopLevelDefs(func(a irA) {
+		switch def := a.(type) {
+		case *irALet:
+			defs[def.NameGo] = def
+			lets = append(lets, def)
+		case *irAFunc:
+			defs[def.NameGo] = def
+		}
+	})
+	if len(lets) == 0 {
+		return
+	}
+	refsall, refseager := irATopLevelRefs{}, irATopLevelRefs{}
+	for name, def := range defs {
+		refsall[name] = irALookupBelowˇTopLevelRefs(def, true, defs)
+		if let, _ := def.(*irALet); let != nil {
+			refseager[name] = irALookupBelowˇTopLevelRefs(let.LetVal, false, defs)
+		}
+	}
+
+	//	all lets in reference cycles (as Go sees them: through func bodies) get deferred
+	isdeferred := map[string]bool{}
+	for _, scc := range refsall.cycles() {
+		for _, name := range scc {
+			if _, islet := defs[name].(*irALet); islet {
+				isdeferred[name] = true
+			}
+		}
+	}
+	if len(isdeferred) == 0 {
+		return
+	}
+
+	//	and so do all lets needing any deferred ones at init time, be it directly or via the funcs they call
+	needs := map[string]map[string]bool{}
+	for _, let := range lets {
+		needs[let.NameGo] = refseager.initTimeNeeds(let.NameGo, refsall, defs)
+	}
+	for again := true; again; {
+		again = false
+		for _, let := range lets {
+			if !isdeferred[let.NameGo] {
+				for need := range needs[let.NameGo] {
+					if isdeferred[need] {
+						isdeferred[let.NameGo], again = true, true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	//	source order, except where a deferred let needs another one that comes later
+	done := map[string]bool{}
+	for len(deferred) < len(isdeferred) {
+		var next *irALet
+		for _, let := range lets {
+			if isdeferred[let.NameGo] && !done[let.NameGo] {
+				if next == nil {
+					next = let // for genuinely eager cycles: PureScript would throw at runtime there too, no order fixes that
+				}
+				isready := true
+				for need := range needs[let.NameGo] {
+					if need != let.NameGo && isdeferred[need] && !done[need] {
+						isready = false
+						break
+					}
+				}
+				if isready {
+					next = let
+					break
+				}
+			}
+		}
+		done[next.NameGo], deferred = true, append(deferred, next)
+	}
+	return
+}
+
+func irALookupBelowˇTopLevelRefs(me irA, intofuncvals bool, defs map[string]irA) (refs map[string]bool) {
+	refs = map[string]bool{}
+	irALookupBelow(me, intofuncvals, func(a irA) bool {
+		//	local names shadowing top-level ones make for some false positives here: harmless, as it only ever defers more than necessary
+		if sym, _ := a.(*irASym); sym != nil && defs[sym.NameGo] != nil {
+			refs[sym.NameGo] = true
+		}
+		return false
+	})
+	return
+}
+
+// initTimeNeeds returns the top-level names whose values name's initializer uses when run: those referenced
+// outside of lambdas, plus everything reachable from the funcs among those (as they might well get called).
+func (me irATopLevelRefs) initTimeNeeds(name string, refsall irATopLevelRefs, defs map[string]irA) map[string]bool {
+	needs, todo := map[string]bool{}, []string{}
+	for ref := range me[name] {
+		todo = append(todo, ref)
+	}
+	for len(todo) > 0 {
+		ref := todo[len(todo)-1]
+		if todo = todo[:len(todo)-1]; !needs[ref] {
+			needs[ref] = true
+			isfunc := false
+			switch def := defs[ref].(type) {
+			case *irAFunc:
+				isfunc = true
+			case *irALet:
+				_, isfunc = def.LetVal.(*irAFunc)
+			}
+			if isfunc {
+				for funcref := range refsall[ref] {
+					todo = append(todo, funcref)
+				}
+			}
+		}
+	}
+	return needs
+}
+
+// cycles returns the strongly connected components (Tarjan) that are cycles: more than 1 name, or 1 referring to itself.
+func (me irATopLevelRefs) cycles() (sccs [][]string) {
+	index, lowlink, onstack, stack := map[string]int{}, map[string]int{}, map[string]bool{}, []string{}
+	var strongconnect func(string)
+	strongconnect = func(name string) {
+		index[name], lowlink[name] = len(index), len(index)
+		stack, onstack[name] = append(stack, name), true
+		for ref := range me[name] {
+			if _, visited := index[ref]; !visited {
+				strongconnect(ref)
+				if lowlink[ref] < lowlink[name] {
+					lowlink[name] = lowlink[ref]
+				}
+			} else if onstack[ref] && index[ref] < lowlink[name] {
+				lowlink[name] = index[ref]
+			}
+		}
+		if lowlink[name] == index[name] {
+			var scc []string
+			for {
+				top := stack[len(stack)-1]
+				stack, onstack[top] = stack[:len(stack)-1], false
+				if scc = append(scc, top); top == name {
+					break
+				}
+			}
+			if len(scc) > 1 || me[name][name] {
+				sccs = append(sccs, scc)
+			}
+		}
+	}
+	for name := range me {
+		if _, visited := index[name]; !visited {
+			strongconnect(name)
+		}
+	}
+	return
+}
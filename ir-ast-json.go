@@ -0,0 +1,260 @@
+package gonad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+/*
+Golang intermediate-representation AST:
+the gonad.ast.json format. Every node is a JSON object tagged
+with its node kind in "ˇ" (eg. "Let" for an irALet), so that
+the file can be loaded back (see --from-ast) to re-generate
+Go code without re-parsing and re-processing coreimp.json,
+be it a hand-patched IR, one from external tools, or just a
+minimal reproduction case. Bump irAstJsonVersion whenever
+the shape of any node kind changes incompatibly.
+*/
+
+const irAstJsonVersion = 1
+
+type irAstJson struct {
+	GonadAstVersion int
+	ModQName        string
+	Body            []json.RawMessage
+	Methods         map[string]json.RawMessage `json:",omitempty"` // the impls of GoTypeDefs' struct methods, by "TypeName.MethodName"
+}
+
+var (
+	irANodeTypes = map[string]reflect.Type{}
+	irAIfaceType = reflect.TypeOf((*irA)(nil)).Elem()
+	irABaseType  = reflect.TypeOf(irABase{})
+)
+
+func init() {
+	for _, a := range []irA{&irABlock{}, &irACall{}, &irAComments{}, &irAConst{}, &irACtor{}, &irADot{}, &irAFor{}, &irAFunc{}, &irAIf{}, &irAIndex{}, &irAIsType{}, &irALet{}, &irALitArr{}, &irALitBool{}, &irALitInt{}, &irALitNum{}, &irALitObj{}, &irALitObjField{}, &irALitStr{}, &irANil{}, &irAOp1{}, &irAOp2{}, &irAPanic{}, &irAPatMatchFail{}, &irAPkgSym{}, &irARet{}, &irASet{}, &irASym{}, &irAToType{}} {
+		t := reflect.TypeOf(a).Elem()
+		irANodeTypes[strings.TrimPrefix(t.Name(), "irA")] = t
+	}
+}
+
+func (me *irAst) writeAsJsonTo(w io.Writer) (err error) {
+	aj := irAstJson{GonadAstVersion: irAstJsonVersion, ModQName: me.mod.qName, Methods: map[string]json.RawMessage{}}
+	for _, a := range me.Body {
+		var raw json.RawMessage
+		if raw, err = irANodeToJson(a); err != nil {
+			return
+		}
+		aj.Body = append(aj.Body, raw)
+	}
+	for _, gtd := range me.irM.GoTypeDefs {
+		if gtd.RefStruct != nil {
+			for _, method := range gtd.RefStruct.Methods {
+				if method.RefFunc != nil && method.RefFunc.impl != nil {
+					if aj.Methods[gtd.NameGo+"."+method.NameGo], err = irANodeToJson(method.RefFunc.impl); err != nil {
+						return
+					}
+				}
+			}
+		}
+	}
+	jsonenc := json.NewEncoder(w)
+	jsonenc.SetIndent("", "\t")
+	return jsonenc.Encode(&aj)
+}
+
+// loadFromJson replaces our Body (and GoTypeDefs' method impls) with those in r, which must have been written by writeAsJsonTo.
+func (me *irAst) loadFromJson(r io.Reader) (err error) {
+	var aj irAstJson
+	if err = json.NewDecoder(r).Decode(&aj); err == nil {
+		if aj.GonadAstVersion != irAstJsonVersion {
+			err = fmt.Errorf("unsupported GonadAstVersion %d (expected %d)", aj.GonadAstVersion, irAstJsonVersion)
+		} else if aj.ModQName != me.mod.qName {
+			err = fmt.Errorf("AST is of module %s, not %s", aj.ModQName, me.mod.qName)
+		}
+	}
+	if err != nil {
+		return
+	}
+	me.irABlock.root, me.Body = me, nil
+	for _, raw := range aj.Body {
+		var a irA
+		if a, err = irANodeFromJson(raw); err != nil {
+			return
+		}
+		me.add(a)
+	}
+	for _, gtd := range me.irM.GoTypeDefs {
+		if gtd.RefStruct != nil {
+			for _, method := range gtd.RefStruct.Methods {
+				if raw := aj.Methods[gtd.NameGo+"."+method.NameGo]; raw != nil && method.RefFunc != nil {
+					var a irA
+					if a, err = irANodeFromJson(raw); err != nil {
+						return
+					} else if method.RefFunc.impl, _ = a.(*irABlock); method.RefFunc.impl == nil {
+						return fmt.Errorf("%s.%s: method impl is a %T, not a Block", gtd.NameGo, method.NameGo, a)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func irANodeToJson(a irA) (json.RawMessage, error) {
+	v := reflect.ValueOf(a).Elem()
+	m := map[string]interface{}{"ˇ": strings.TrimPrefix(v.Type().Name(), "irA")}
+	if err := irANodeFieldsToJson(v, m, a); err != nil {
+		return nil, err
+	}
+	//	the few unexported bits that codegen relies on
+	switch x := a.(type) {
+	case *irALitStr:
+		if x.goNative {
+			m["ˇgoNative"] = true
+		}
+	case *irALet:
+		if x.typeConv.okname != "" {
+			m["ˇtypeConv"] = []interface{}{x.typeConv.okname, x.typeConv.vused}
+		}
+	case *irAIsType:
+		m["ˇnames"] = []string{x.names.v, x.names.t}
+	}
+	return json.Marshal(m)
+}
+
+func irANodeFieldsToJson(v reflect.Value, m map[string]interface{}, a irA) (err error) {
+	for i, t := 0, v.Type(); i < t.NumField() && err == nil; i++ {
+		f, fv := t.Field(i), v.Field(i)
+		switch {
+		case f.Type == irABaseType:
+			base := a.Base() // the embedded irABase is unexported, so not reflect-accessible
+			if base.NameGo != "" || base.NamePs != "" || base.hasTypeInfo() || base.Export {
+				m["Type"] = &base.irANamedTypeRef
+			}
+			if len(base.Comments) > 0 {
+				m["Comments"] = base.Comments
+			}
+		case f.Anonymous: // eg. the irAFunc in an irACtor
+			err = irANodeFieldsToJson(fv, m, a)
+		case f.PkgPath != "" || strings.HasSuffix(f.Name, "__"): // unexported, or useless (like Sym__)
+		case f.Type.Implements(irAIfaceType):
+			if !irAIsNil(irAOrNil(fv)) {
+				m[f.Name], err = irANodeToJson(fv.Interface().(irA))
+			}
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Implements(irAIfaceType):
+			raws := make([]json.RawMessage, fv.Len())
+			for j := range raws {
+				if raws[j], err = irANodeToJson(fv.Index(j).Interface().(irA)); err != nil {
+					break
+				}
+			}
+			m[f.Name] = raws
+		default:
+			m[f.Name] = fv.Interface()
+		}
+	}
+	return
+}
+
+func irAOrNil(fv reflect.Value) irA {
+	if (fv.Kind() == reflect.Interface || fv.Kind() == reflect.Ptr) && fv.IsNil() {
+		return nil
+	}
+	return fv.Interface().(irA)
+}
+
+func irANodeFromJson(raw json.RawMessage) (a irA, err error) {
+	var m map[string]json.RawMessage
+	var kind string
+	if err = json.Unmarshal(raw, &m); err == nil {
+		if err = json.Unmarshal(m["ˇ"], &kind); err == nil {
+			if t := irANodeTypes[kind]; t == nil {
+				err = fmt.Errorf("unknown node kind '%s'", kind)
+			} else {
+				pv := reflect.New(t)
+				a = pv.Interface().(irA)
+				err = irANodeFieldsFromJson(pv.Elem(), m, a)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch x := a.(type) {
+	case *irALitStr:
+		if raw := m["ˇgoNative"]; raw != nil {
+			err = json.Unmarshal(raw, &x.goNative)
+		}
+	case *irALet:
+		if raw := m["ˇtypeConv"]; raw != nil {
+			tc := []interface{}{&x.typeConv.okname, &x.typeConv.vused}
+			err = json.Unmarshal(raw, &tc)
+		}
+	case *irAIsType:
+		if raw := m["ˇnames"]; raw != nil {
+			names := []*string{&x.names.v, &x.names.t}
+			err = json.Unmarshal(raw, &names)
+		}
+	case *irACtor:
+		if x.RefFunc != nil {
+			x.RefFunc.impl = x.FuncImpl
+		}
+	case *irAFunc:
+		if x.RefFunc != nil {
+			x.RefFunc.impl = x.FuncImpl // as set up by astToIrA
+		}
+	}
+	return
+}
+
+func irANodeFieldsFromJson(v reflect.Value, m map[string]json.RawMessage, parent irA) (err error) {
+	for i, t := 0, v.Type(); i < t.NumField() && err == nil; i++ {
+		f, fv := t.Field(i), v.Field(i)
+		switch {
+		case f.Type == irABaseType:
+			base := parent.Base()
+			if raw := m["Type"]; raw != nil {
+				err = json.Unmarshal(raw, &base.irANamedTypeRef)
+			}
+			if raw := m["Comments"]; raw != nil && err == nil {
+				err = json.Unmarshal(raw, &base.Comments)
+			}
+		case f.Anonymous:
+			err = irANodeFieldsFromJson(fv, m, parent)
+		case f.PkgPath != "" || strings.HasSuffix(f.Name, "__") || m[f.Name] == nil:
+		case f.Type.Implements(irAIfaceType):
+			var child irA
+			if child, err = irANodeFromJson(m[f.Name]); err == nil {
+				err = irASetChildField(fv, f.Type, child, parent)
+			}
+		case f.Type.Kind() == reflect.Slice && f.Type.Elem().Implements(irAIfaceType):
+			var raws []json.RawMessage
+			if err = json.Unmarshal(m[f.Name], &raws); err == nil {
+				fv.Set(reflect.MakeSlice(f.Type, len(raws), len(raws)))
+				for j := 0; j < len(raws) && err == nil; j++ {
+					var child irA
+					if child, err = irANodeFromJson(raws[j]); err == nil {
+						err = irASetChildField(fv.Index(j), f.Type.Elem(), child, parent)
+					}
+				}
+			}
+		default:
+			err = json.Unmarshal(m[f.Name], fv.Addr().Interface())
+		}
+	}
+	return
+}
+
+func irASetChildField(fv reflect.Value, ft reflect.Type, child irA, parent irA) error {
+	cv := reflect.ValueOf(child)
+	if !cv.Type().AssignableTo(ft) {
+		return fmt.Errorf("a %T cannot go where a %v is expected", child, ft)
+	}
+	fv.Set(cv)
+	child.Base().parent = parent
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package gonad
 
 import (
 	"fmt"
@@ -9,11 +9,20 @@ import (
 Golang intermediate-representation AST:
 various transforms and operations on the AST,
 "prep" ops are called from prepFromCoreImp
-and "post" ops are called from finalizePostPrepOps.
+and "post" ops are the passes in ir-passes.go.
 */
 
 func (me *irAst) finalizePostPrepOps() {
-	//	various fix-ups
+	sess := me.mod.proj.sess
+	for i, pass := range sess.irPassPipeline {
+		if !pass.prep {
+			pass.runOn(me, &sess.irPassRunTimes[i])
+		}
+	}
+	me.dumpIrStage("post")
+}
+
+func (me *irAst) postFixupAmpCtors() {
 	me.walk(func(ast irA) irA {
 		if ast != nil {
 			switch a := ast.(type) {
@@ -27,13 +36,6 @@ func (me *irAst) finalizePostPrepOps() {
 		}
 		return ast
 	})
-
-	me.postLinkUpTcMemberFuncs()
-	me.postLinkUpTcInstDecls()
-	me.postInitialFixups()
-	me.postEnsureArgTypes()
-	me.postPerFuncFixups()
-	me.postFinalFixups()
 }
 
 func (me *irAst) postEnsureArgTypes() {
@@ -42,7 +44,7 @@ func (me *irAst) postEnsureArgTypes() {
 		switch atld := a.(type) {
 		case *irAFunc:
 			if atld.NamePs == "" {
-				panic(fmt.Sprintf("%T", atld.parent))
+				panic(fmt.Errorf("%s: nameless top-level func under %T", me.mod.srcFilePath, atld.parent)) // astToIrA rejects those in coreimp.json, so it's a bug
 			} else if gvd := me.irM.goValDeclByPsName(atld.NamePs); gvd != nil && gvd.RefFunc != nil {
 				if tlcmem := me.irM.tcMember(atld.NamePs); tlcmem == nil {
 					atld.RefFunc.copyArgTypesOnlyFrom(false, gvd.RefFunc)
@@ -52,8 +54,10 @@ func (me *irAst) postEnsureArgTypes() {
 			if !atld.ExprType().hasTypeInfo() {
 				if gvd := me.irM.goValDeclByPsName(atld.NamePs); gvd != nil {
 					atld.copyTypeInfoFrom(gvd)
-					if lval := atld.LetVal.Base(); !lval.hasTypeInfo() {
-						lval.copyTypeInfoFrom(gvd)
+					if atld.LetVal != nil {
+						if lval := atld.LetVal.Base(); !lval.hasTypeInfo() {
+							lval.copyTypeInfoFrom(gvd)
+						}
 					}
 				}
 			}
@@ -68,7 +72,7 @@ func (me *irAst) postEnsureArgTypes() {
 			if len(fn.RefFunc.Rets) > 1 {
 				panic(notImplErr("multiple ret-args in func", fn.NamePs, me.mod.srcFilePath))
 			}
-			if !fn.RefFunc.Rets[0].hasTypeInfo() {
+			if len(fn.RefFunc.Rets) == 1 && !fn.RefFunc.Rets[0].hasTypeInfo() {
 				walk(fn.FuncImpl, false, func(stmt irA) irA {
 					if !fn.RefFunc.Rets[0].hasTypeInfo() {
 						if ret, _ := stmt.(*irARet); ret != nil {
@@ -97,7 +101,7 @@ func (me *irAst) postEnsureArgTypes() {
 		}
 		if !fn.RefFunc.haveAllArgsTypeInfo() {
 			if fnretouter, _ := fn.parent.(*irARet); fnretouter != nil {
-				if fnouter, _ := fnretouter.parent.Parent().(*irAFunc); fnouter != nil {
+				if fnouter, _ := fnretouter.parent.Parent().(*irAFunc); fnouter != nil && len(fnouter.RefFunc.Rets) > 0 {
 					if fnretsig := fnouter.RefFunc.Rets[0].RefFunc; fnretsig != nil {
 						if len(fnretsig.Args) != len(fn.RefFunc.Args) || len(fnretsig.Rets) != len(fn.RefFunc.Rets) {
 							panic(notImplErr("func-args count mismatch", fnouter.NamePs, me.mod.srcFilePath))
@@ -115,14 +119,14 @@ func (me *irAst) postEnsureArgTypes() {
 							}
 						} else if fnretsym, _ := fnret.RetArg.(*irASym); fnretsym != nil {
 							if symref := fnretsym.refTo(); symref != nil {
-								if (!fnretsym.ExprType().hasTypeInfo()) && fnouter.RefFunc.Rets[0].hasTypeInfo() {
+								if (!fnretsym.ExprType().hasTypeInfo()) && len(fnouter.RefFunc.Rets) > 0 && fnouter.RefFunc.Rets[0].hasTypeInfo() {
 									if !symref.ExprType().hasTypeInfo() {
 										symref.Base().copyTypeInfoFrom(fnouter.RefFunc.Rets[0])
 									}
 								}
 								if symvar, _ := symref.(*irALet); symvar != nil {
 									if symvarset := symvar.setterFromCallTo(fnletouter); symvarset != nil {
-										if fnretsym.ExprType().hasTypeInfo() {
+										if fnretsym.ExprType().hasTypeInfo() && len(fn.RefFunc.Rets) > 0 {
 											fn.RefFunc.Rets[0].copyTypeInfoFrom(fnretsym.ExprType())
 										}
 									}
@@ -139,6 +143,10 @@ func (me *irAst) postEnsureArgTypes() {
 func (me *irAst) postFinalFixups() {
 	me.walk(func(ast irA) irA {
 		switch a := ast.(type) {
+		case *irALitStr:
+			if !a.goNative && a.isCharExpected() {
+				a.RefAlias = exprTypeChar.RefAlias // for codeGenAst, which then emits a Char rather than String literal
+			}
 		case *irALitObj:
 			//	record literals that *could* be matched to an existing struct (hopefully all of them) now need field names fixed up
 			if atl := a.ExprType(); atl.RefAlias != "" {
@@ -180,7 +188,7 @@ func (me *irAst) postFixupAmpCtor(a *irAOp1, oc *irACall) irA {
 	var gtd *irANamedTypeRef
 	var mod *modPkg
 	if ocpkgsym, _ := oc.Callee.(*irAPkgSym); ocpkgsym != nil {
-		if mod = findModuleByPName(ocpkgsym.PkgName); mod != nil {
+		if mod = me.mod.findModuleByPName(ocpkgsym.PkgName); mod != nil {
 			gtd = mod.irMeta.goTypeDefByPsName(ocpkgsym.Symbol)
 		}
 	}
@@ -200,32 +208,39 @@ func (me *irAst) postFixupAmpCtor(a *irAOp1, oc *irACall) irA {
 		}
 		return o
 	} else if ocv != nil && ocv.NamePs == "Error" {
+		//	JS's `new Error(..)` becomes gonad's runtime error type (see gonadz/err.go) that all throws panic with
 		if len(oc.CallArgs) == 1 {
 			if op2, _ := oc.CallArgs[0].(*irAOp2); op2 != nil && op2.Op2 == "+" {
-				oc.CallArgs[0] = op2.Left
-				op2.Left.Base().parent = oc
-				if oparr := op2.Right.(*irALitArr); oparr != nil {
-					for _, oparrelem := range oparr.ArrVals {
-						nucallarg := oparrelem
-						if oaedot, _ := oparrelem.(*irADot); oaedot != nil {
-							if oaedot2, _ := oaedot.DotLeft.(*irADot); oaedot2 != nil {
-								nucallarg = oaedot2.DotLeft
-							} else {
-								nucallarg = oaedot
+				if oplit, _ := op2.Left.(*irALitStr); oplit != nil {
+					if oparr, _ := op2.Right.(*irALitArr); oparr != nil {
+						vals := make([]irA, 0, len(oparr.ArrVals))
+						for _, oparrelem := range oparr.ArrVals {
+							val := oparrelem // usually `v.constructor.name`, we want just the `v`
+							if oaedot, _ := oparrelem.(*irADot); oaedot != nil {
+								if oaedot2, _ := oaedot.DotLeft.(*irADot); oaedot2 != nil {
+									val = oaedot2.DotLeft
+								} else {
+									val = oaedot
+								}
 							}
+							vals = append(vals, val)
 						}
-						oc.CallArgs = append(oc.CallArgs, ªCall(ªPkgSym("reflect", "TypeOf"), nucallarg))
-						oc.CallArgs = append(oc.CallArgs, nucallarg)
+						if patfail := ªPatMatchFail(oplit.LitStr, vals...); patfail != nil {
+							return patfail
+						}
+						//	some other "msg: " + [vals], formatted via 𝒈.Errf
+						oplit.goNative = true
+						oc.CallArgs[0] = oplit
+						for _, val := range vals {
+							oc.CallArgs = append(oc.CallArgs, val, val)
+						}
+						oplit.LitStr = strings.Replace(oplit.LitStr, "%", "%%", -1) + strings.Repeat(", ‹%T› %v", len(vals))[2:]
+						return ªCall(ªPkgSym("𝒈", "Errf"), oc.CallArgs...)
 					}
 				}
-				if len(oc.CallArgs) > 1 {
-					me.irM.ensureImp("reflect", "", "")
-					oc.CallArgs[0].(*irALitStr).LitStr += strings.Repeat(", ‹%v› %v", (len(oc.CallArgs)-1)/2)[2:]
-				}
 			}
 		}
-		call := ªCall(ªPkgSym("fmt", "Errorf"), oc.CallArgs...)
-		return call
+		return ªCall(ªPkgSym("𝒈", "Err"), oc.CallArgs...)
 	} else if ocv != nil {
 		// println("TODO:\t" + me.mod.srcFilePath + "\t" + ocv.NamePs)
 	}
@@ -309,12 +324,12 @@ func (me *irAst) postPerFuncFixups() {
 				walk(ax, false, func(ast irA) irA {
 					switch a := ast.(type) {
 					case *irARet:
-						if a.RetArg == nil {
+						if a.RetArg == nil && len(afn.RefFunc.Args) > 0 {
 							retarg := ªSymPs(afn.RefFunc.Args[0].NamePs, false)
 							retarg.copyTypeInfoFrom(afn.RefFunc.Args[0])
 							retarg.parent, a.RetArg = a, retarg
 						}
-						if afn.RefFunc.Rets[0].hasTypeInfo() {
+						if len(afn.RefFunc.Rets) > 0 && afn.RefFunc.Rets[0].hasTypeInfo() {
 							if aretsym, _ := a.RetArg.(*irASym); aretsym != nil {
 								if tretsym := aretsym.ExprType(); (!tretsym.hasTypeInfoBeyondEmptyIface()) && !tretsym.equiv(afn.RefFunc.Rets[0]) {
 									i, varname = convertToTypeOf(i, afn, a.RetArg, afn.RefFunc.Rets[0])
@@ -411,7 +426,7 @@ func (me *irAst) postLinkUpTcInstDecls() {
 								case *irALitObj:
 								case *irAOp2:
 								default:
-									println(fvx.(*irAFunc))
+									panic(notImplErr(fmt.Sprintf("type-class instance field value (%T) for", fvx), ab.NamePs+"."+gtd.RefStruct.Fields[i].NamePs, me.mod.srcFilePath))
 								}
 							}
 							if ax.RefAlias = axlv.RefAlias; gtd.RefStruct.PassByPtr {
@@ -424,7 +439,7 @@ func (me *irAst) postLinkUpTcInstDecls() {
 							ax.RefAlias = tci.ClassName
 						case *irACall:
 						default:
-							panicWithType(me.mod.srcFilePath, axlv, ab.NamePs+".LetVal")
+							panic(notImplErr(fmt.Sprintf("type-class instance value (%T) for", axlv), ab.NamePs, me.mod.srcFilePath))
 						}
 					case *irAFunc:
 						if len(ax.RefFunc.Args) != 1 {
@@ -459,7 +474,7 @@ func (me *irAst) postLinkUpTcInstDecls() {
 							case *irACall:
 								retmod, retgtd = tcmod, gtd
 							default:
-								panicWithType(me.mod.srcFilePath, axr, tci.Name)
+								panic(notImplErr(fmt.Sprintf("type-class instance func return (%T) for", axr), tci.Name, me.mod.srcFilePath))
 							}
 							if retgtd != nil {
 								fnretarg := irANamedTypeRef{RefAlias: retgtd.NameGo}
@@ -473,7 +488,7 @@ func (me *irAst) postLinkUpTcInstDecls() {
 							}
 						}
 					default:
-						panicWithType(me.mod.srcFilePath, ax, tci.Name)
+						panic(notImplErr(fmt.Sprintf("type-class instance decl (%T) for", ax), tci.Name, me.mod.srcFilePath))
 					}
 				}
 			}
@@ -1,10 +1,10 @@
-package main
+package gonad
 
 /*
 Golang intermediate-representation AST:
 various transforms and operations on the AST,
 "prep" ops are called from prepFromCoreImp
-and "post" ops are called from finalizePostPrepOps.
+and "post" ops are the passes in ir-passes.go.
 */
 
 func (me *irAst) prepFromCoreImp() {
@@ -16,6 +16,7 @@ func (me *irAst) prepFromCoreImp() {
 	for _, cia := range me.mod.coreimp.Body { // traverse the original CoreImp AST
 		me.prepAddOrCull(me.mod.coreimp.astToIrA(cia)) // convert every top-level node into our Golang IR
 	}
+	me.verifyAfter("prep step astToIrA")
 	//	at this point, the Golang IR highly resembles CoreImp ie JS. No types, lots of closures etc.
 	//	here begins to long arduous road to transform into more idiomatic well-typed Golang.
 
@@ -27,13 +28,18 @@ func (me *irAst) prepFromCoreImp() {
 		}
 	}
 
-	if reqforeign := me.mod.coreimp.My.NamedRequires["$foreign"]; reqforeign != "" {
+	if reqforeign := me.mod.coreimp.My.NamedRequires["$foreign"]; reqforeign != "" && len(me.mod.ffiFilePaths) == 0 {
 		me.irM.ForeignImp = me.irM.ensureImp("", prefixDefaultFfiPkgImpPath+strReplDot2Slash.Replace(me.mod.qName), "")
+		me.prepAddForeignValReExports()
+		me.verifyAfter("prep step prepAddForeignValReExports")
 	}
 
 	me.prepFixupNameCasings()
+	me.verifyAfter("prep step prepFixupNameCasings")
 	nuglobals := me.prepAddEnumishAdtGlobals()
+	me.verifyAfter("prep step prepAddEnumishAdtGlobals")
 	me.prepMiscFixups(nuglobals)
+	me.verifyAfter("prep step prepMiscFixups")
 }
 
 func (me *irAst) prepAddOrCull(a irA) {
@@ -49,7 +55,9 @@ func (me *irAst) prepAddOrCull(a irA) {
 						} else { // for some freakish reason, ctor-func args are OFTEN BUT NOT ALWAYS in the same order as struct-from-type-syn fields: we fix the field order to match ctor-func args order
 							reordered := make(irANamedTypeRefs, numargs, numargs)
 							for i := 0; i < numargs; i++ {
-								reordered[i] = gtd.RefStruct.Fields.byPsName(ctor.RefFunc.Args[i].NamePs)
+								if reordered[i] = gtd.RefStruct.Fields.byPsName(ctor.RefFunc.Args[i].NamePs); reordered[i] == nil {
+									panic(notImplErr("type-class "+tc.Name+" ctor-func arg", ctor.RefFunc.Args[i].NamePs, me.mod.srcFilePath))
+								}
 							}
 							gtd.RefStruct.Fields = reordered
 						}
@@ -83,6 +91,26 @@ func (me *irAst) prepAddEnumishAdtGlobals() (nuglobalsmap map[string]*irALet) {
 	return
 }
 
+func (me *irAst) prepAddForeignValReExports() {
+	//	other modules refer to our foreign imports as members of our package, but the default FFI packages hold them: so re-export
+	for _, fvname := range me.irM.EnvForeignVals {
+		if gvd := me.irM.goValDeclByPsName(fvname); gvd != nil && gvd.Export {
+			nuvar := ªLet("", fvname, me.prepForeignValRef(prefixDefaultFfiPkgNs+strReplDot2ˈ.Replace(me.mod.qName), ªSymPs(fvname, true)))
+			nuvar.copyTypeInfoFrom(gvd)
+			me.add(nuvar)
+		}
+	}
+}
+
+func (me *irAst) prepForeignValRef(pkgname string, fv *irASym) *irAPkgSym {
+	fv.Export, fv.NameGo = true, me.irM.goNameForForeignVal(fv.NamePs)
+	pkgsym := ªPkgSym(pkgname, fv.NameGo)
+	if gvd := me.irM.goValDeclByPsName(fv.NamePs); gvd != nil {
+		pkgsym.copyTypeInfoFrom(gvd)
+	}
+	return pkgsym
+}
+
 func (me *irAst) prepAddNewExtraTypesˇTypeClassInstances() {
 	// var newextratypes irANamedTypeRefs
 	// //	turn type-class instances into unexported 0-byte structs providing the corresponding interface-implementing method(s)
@@ -137,7 +165,12 @@ func (me *irAst) prepMiscFixups(nuglobalsmap map[string]*irALet) {
 								tconvt := &irANamedTypeRef{RefAlias: tcheck.TypeToTest}
 								if tconv == nil {
 									pname, tname := me.resolveGoTypeRefFromQName(tcheck.TypeToTest)
-									tconvto := ªTo(tcheck.ExprToTest, pname, tname)
+									exprtoconv := tcheck.ExprToTest
+									if sym, _ := exprtoconv.(*irASym); sym != nil { // the type-check keeps its own
+										symcopy := *sym
+										exprtoconv = &symcopy
+									}
+									tconvto := ªTo(exprtoconv, pname, tname)
 									tconv = ªLet(tchkey, "", tconvto)
 									tconv.typeConv.okname, tconv.parent = "ː"+tchkey, afn.FuncImpl
 									tconv.copyTypeInfoFrom(tconvt)
@@ -179,16 +212,18 @@ func (me *irAst) prepMiscFixups(nuglobalsmap map[string]*irALet) {
 							//	if the dot's LHS refers to a package, ensure the import is there and switch out irADot for irAPkgSym
 							for _, imp := range me.irM.Imports {
 								if imp.GoName == dl.NameGo || (dl.NamePs == "$foreign" && imp == me.irM.ForeignImp) {
-									dr.Export = true
-									dr.NameGo = sanitizeSymbolForGo(dr.NameGo, dr.Export)
-									return ªPkgSym(prefixDefaultFfiPkgNs+strReplDot2ˈ.Replace(me.mod.qName), dr.NameGo)
+									return me.prepForeignValRef(prefixDefaultFfiPkgNs+strReplDot2ˈ.Replace(me.mod.qName), dr)
 								}
 							}
+							if dl.NamePs == "$foreign" && len(me.mod.ffiFilePaths) > 0 {
+								//	user-supplied FFI gets copied right into our own package
+								return me.prepForeignValRef("", dr)
+							}
 						}
 					}
 				}
 			case *irABlock:
-				if a != nil && Proj.BowerJsonFile.Gonad.CodeGen.FlattenIfs { // any 2 consecutive ifs-without-elses offer opportunities
+				if a != nil && me.mod.proj.sess.irPassEnabled("flattenIfs") { // any 2 consecutive ifs-without-elses offer opportunities
 					var lastif *irAIf
 					for i := 0; i < len(a.Body); i++ {
 						switch thisif := a.Body[i].(type) {
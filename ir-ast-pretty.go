@@ -0,0 +1,280 @@
+package gonad
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+Golang intermediate-representation AST:
+a compact S-expression rendering for humans (and diff),
+as used by --print-after and the --dump-ir snapshots.
+Types (from ExprType) are shown in ‹these› wherever known.
+*/
+
+type irPrinter struct {
+	w io.Writer
+}
+
+func (me *irAst) writeAsPrettyTo(w io.Writer) {
+	p := irPrinter{w: w}
+	for _, a := range me.Body {
+		p.stmt(0, a)
+		fmt.Fprint(w, "\n")
+	}
+}
+
+func irATypeStr(t *irANamedTypeRef) string {
+	switch {
+	case t == nil || !t.hasTypeInfo():
+		return "?"
+	case t.RefAlias != "":
+		return t.RefAlias
+	case t.RefUnknown != 0:
+		return "?" + strconv.Itoa(t.RefUnknown)
+	case t.RefArray != nil:
+		return "[]" + irATypeStr(t.RefArray.Of)
+	case t.RefPtr != nil:
+		return "*" + irATypeStr(t.RefPtr.Of)
+	case t.RefFunc != nil:
+		s := "func(" + irATypeStrs(t.RefFunc.Args) + ")"
+		if len(t.RefFunc.Rets) == 1 {
+			s += " " + irATypeStr(t.RefFunc.Rets[0])
+		} else if len(t.RefFunc.Rets) > 1 {
+			s += " (" + irATypeStrs(t.RefFunc.Rets) + ")"
+		}
+		return s
+	case t.RefStruct != nil:
+		fields := make([]string, 0, len(t.RefStruct.Fields))
+		for _, fld := range t.RefStruct.Fields {
+			fields = append(fields, fld.NameGo+" "+irATypeStr(fld))
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	case t.RefInterface != nil:
+		if len(t.RefInterface.Embeds) > 0 || len(t.RefInterface.Methods) > 0 {
+			return fmt.Sprintf("interface{%s +%d methods}", strings.Join(t.RefInterface.Embeds, "; "), len(t.RefInterface.Methods))
+		}
+		return "interface{}"
+	}
+	return "?"
+}
+
+func irATypeStrs(ts irANamedTypeRefs) string {
+	strs := make([]string, 0, len(ts))
+	for _, t := range ts {
+		strs = append(strs, irATypeStr(t))
+	}
+	return strings.Join(strs, ", ")
+}
+
+// typed renders ‹the type› of a, or nothing if it has no type info (yet)
+func (me *irPrinter) typed(a irA) {
+	if t := a.ExprType(); t.hasTypeInfo() {
+		fmt.Fprintf(me.w, " ‹%s›", irATypeStr(t))
+	}
+}
+
+func (me *irPrinter) name(a irA) string {
+	if ab := a.Base(); ab.NameGo != "" {
+		return ab.NameGo
+	} else if ab.NamePs != "" {
+		return "ᵖˢ" + ab.NamePs
+	}
+	return "_"
+}
+
+func (me *irPrinter) block(ind int, a *irABlock) {
+	if a != nil {
+		for _, stmt := range a.Body {
+			fmt.Fprint(me.w, "\n")
+			me.stmt(ind, stmt)
+		}
+	}
+}
+
+func (me *irPrinter) stmt(ind int, a irA) {
+	fmt.Fprint(me.w, strings.Repeat("  ", ind))
+	me.expr(ind, a)
+}
+
+func (me *irPrinter) exprs(ind int, asts []irA) {
+	for _, a := range asts {
+		fmt.Fprint(me.w, " ")
+		me.expr(ind, a)
+	}
+}
+
+// expr renders a (statement or expression) at the current position, further lines (if any) indented by ind+1
+func (me *irPrinter) expr(ind int, a irA) {
+	if irAIsNil(a) {
+		fmt.Fprint(me.w, "(nil)")
+		return
+	}
+	switch x := a.(type) {
+	case *irAComments:
+		for i, c := range x.Comments {
+			if i > 0 {
+				fmt.Fprint(me.w, "\n"+strings.Repeat("  ", ind))
+			}
+			fmt.Fprint(me.w, ";; "+strings.TrimSpace(c.LineComment+c.BlockComment))
+		}
+	case *irALet:
+		fmt.Fprint(me.w, "(let "+me.name(x))
+		me.typed(x)
+		fmt.Fprint(me.w, " ")
+		me.expr(ind, x.LetVal)
+		fmt.Fprint(me.w, ")")
+	case *irAConst:
+		fmt.Fprint(me.w, "(const "+me.name(x))
+		me.typed(x)
+		fmt.Fprint(me.w, " ")
+		me.expr(ind, x.ConstVal)
+		fmt.Fprint(me.w, ")")
+	case *irASet:
+		fmt.Fprint(me.w, "(set ")
+		me.expr(ind, x.SetLeft)
+		fmt.Fprint(me.w, " ")
+		me.expr(ind, x.ToRight)
+		fmt.Fprint(me.w, ")")
+	case *irARet:
+		fmt.Fprint(me.w, "(ret")
+		if x.RetArg != nil {
+			fmt.Fprint(me.w, " ")
+			me.expr(ind, x.RetArg)
+		}
+		fmt.Fprint(me.w, ")")
+	case *irAPanic:
+		fmt.Fprint(me.w, "(panic ")
+		me.expr(ind, x.PanicArg)
+		fmt.Fprint(me.w, ")")
+	case *irAIf:
+		fmt.Fprint(me.w, "(if ")
+		me.expr(ind, x.If)
+		me.block(ind+1, x.Then)
+		if x.Else != nil {
+			fmt.Fprint(me.w, "\n"+strings.Repeat("  ", ind)+" else")
+			me.block(ind+1, x.Else)
+		}
+		fmt.Fprint(me.w, ")")
+	case *irAFor:
+		fmt.Fprint(me.w, "(for")
+		if x.ForRange != nil {
+			fmt.Fprint(me.w, " range ")
+			me.stmt(0, x.ForRange)
+		} else {
+			for _, fi := range x.ForInit {
+				fmt.Fprint(me.w, " ")
+				me.stmt(0, fi)
+			}
+			if x.ForCond != nil {
+				fmt.Fprint(me.w, " ")
+				me.expr(ind, x.ForCond)
+			}
+			for _, fs := range x.ForStep {
+				fmt.Fprint(me.w, " ")
+				me.stmt(0, fs)
+			}
+		}
+		me.block(ind+1, x.ForDo)
+		fmt.Fprint(me.w, ")")
+	case *irABlock:
+		fmt.Fprint(me.w, "(block")
+		me.block(ind+1, x)
+		fmt.Fprint(me.w, ")")
+	case *irASym:
+		fmt.Fprint(me.w, me.name(x))
+	case *irAPkgSym:
+		fmt.Fprint(me.w, typeNameWithPkgName(x.PkgName, x.Symbol))
+	case *irANil:
+		fmt.Fprint(me.w, "nil")
+	case *irALitBool:
+		fmt.Fprint(me.w, x.LitBool)
+	case *irALitInt:
+		fmt.Fprint(me.w, x.LitInt)
+	case *irALitNum:
+		fmt.Fprint(me.w, strconv.FormatFloat(x.LitNum, 'g', -1, 64))
+	case *irALitStr:
+		fmt.Fprint(me.w, strconv.Quote(x.LitStr))
+	case *irALitArr:
+		fmt.Fprint(me.w, "(arr")
+		me.typed(x)
+		me.exprs(ind, x.ArrVals)
+		fmt.Fprint(me.w, ")")
+	case *irALitObj:
+		fmt.Fprint(me.w, "(obj")
+		me.typed(x)
+		for _, of := range x.ObjFields {
+			fmt.Fprint(me.w, " ("+me.name(of)+" ")
+			me.expr(ind, of.FieldVal)
+			fmt.Fprint(me.w, ")")
+		}
+		fmt.Fprint(me.w, ")")
+	case *irACall:
+		fmt.Fprint(me.w, "(call")
+		me.typed(x)
+		me.exprs(ind, append([]irA{x.Callee}, x.CallArgs...))
+		fmt.Fprint(me.w, ")")
+	case *irAOp1:
+		fmt.Fprint(me.w, "("+x.Op1)
+		me.typed(x)
+		me.exprs(ind, []irA{x.Of})
+		fmt.Fprint(me.w, ")")
+	case *irAOp2:
+		fmt.Fprint(me.w, "("+x.Op2)
+		me.typed(x)
+		me.exprs(ind, []irA{x.Left, x.Right})
+		fmt.Fprint(me.w, ")")
+	case *irADot:
+		fmt.Fprint(me.w, "(.")
+		me.exprs(ind, []irA{x.DotLeft, x.DotRight})
+		fmt.Fprint(me.w, ")")
+	case *irAIndex:
+		fmt.Fprint(me.w, "(idx")
+		me.exprs(ind, []irA{x.IdxLeft, x.IdxRight})
+		fmt.Fprint(me.w, ")")
+	case *irAIsType:
+		fmt.Fprint(me.w, "(is? "+x.TypeToTest)
+		me.exprs(ind, []irA{x.ExprToTest})
+		fmt.Fprint(me.w, ")")
+	case *irAToType:
+		fmt.Fprint(me.w, "(as "+typeNameWithPkgName(x.TypePkg, x.TypeName))
+		me.exprs(ind, []irA{x.ExprToConv})
+		fmt.Fprint(me.w, ")")
+	case *irAPatMatchFail:
+		fmt.Fprintf(me.w, "(patfail %s %v", x.ModQName, x.SrcPos)
+		me.exprs(ind, x.Scrutinees)
+		fmt.Fprint(me.w, ")")
+	case *irACtor:
+		me.fn(ind, "ctor", &x.irAFunc)
+	case *irAFunc:
+		if x.NameGo != "" || x.NamePs != "" {
+			me.fn(ind, "func "+me.name(x), x)
+		} else {
+			me.fn(ind, "fn", x)
+		}
+	default:
+		fmt.Fprintf(me.w, "(%T)", a)
+	}
+}
+
+func (me *irPrinter) fn(ind int, head string, a *irAFunc) {
+	fmt.Fprint(me.w, "("+head+" (")
+	if a.RefFunc != nil {
+		for i, arg := range a.RefFunc.Args {
+			if i > 0 {
+				fmt.Fprint(me.w, " ")
+			}
+			fmt.Fprintf(me.w, "%s ‹%s›", arg.NameGo, irATypeStr(arg))
+		}
+		fmt.Fprint(me.w, ")")
+		if len(a.RefFunc.Rets) > 0 {
+			fmt.Fprintf(me.w, " ‹%s›", irATypeStrs(a.RefFunc.Rets))
+		}
+	} else {
+		fmt.Fprint(me.w, ")")
+	}
+	me.block(ind+1, a.FuncImpl)
+	fmt.Fprint(me.w, ")")
+}
@@ -1,4 +1,4 @@
-package main
+package gonad
 
 /*
 Golang intermediate-representation AST:
@@ -180,6 +180,10 @@ func walk(ast irA, intofuncvals bool, on funcIra2Ira) irA {
 			a.Left, a.Right = walk(a.Left, intofuncvals, on), walk(a.Right, intofuncvals, on)
 		case *irAPanic:
 			a.PanicArg = walk(a.PanicArg, intofuncvals, on)
+		case *irAPatMatchFail:
+			for i, sv := range a.Scrutinees {
+				a.Scrutinees[i] = walk(sv, intofuncvals, on)
+			}
 		case *irARet:
 			a.RetArg = walk(a.RetArg, intofuncvals, on)
 		case *irASet:
@@ -1,10 +1,10 @@
-package main
+package gonad
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 
 	"github.com/metaleap/go-util/dev/ps"
@@ -23,6 +23,7 @@ var (
 	exprTypeInt  = &irANamedTypeRef{RefAlias: "Prim.Int"}
 	exprTypeNum  = &irANamedTypeRef{RefAlias: "Prim.Number"}
 	exprTypeStr  = &irANamedTypeRef{RefAlias: "Prim.String"}
+	exprTypeChar = &irANamedTypeRef{RefAlias: "Prim.Char"}
 	exprTypeBool = &irANamedTypeRef{RefAlias: "Prim.Boolean"}
 )
 
@@ -128,6 +129,7 @@ type irALet struct {
 		okname string
 		vused  bool
 	}
+	resolving bool // set while following LetVal, which (in broken input) might lead back here via syms, eg. var a = b; var b = a
 }
 
 func (me *irALet) callers() (all []*irACall) {
@@ -148,16 +150,21 @@ func (me *irALet) Equiv(cmp irA) bool {
 }
 
 func (me *irALet) isConstable() bool {
-	if c, _ := me.LetVal.(irAConstable); c != nil {
-		return c.isConstable()
+	if c, _ := me.LetVal.(irAConstable); c != nil && !me.resolving {
+		me.resolving = true
+		isconstable := c.isConstable()
+		me.resolving = false
+		return isconstable
 	}
 	return false
 }
 
 func (me *irALet) ExprType() *irANamedTypeRef {
 	if !me.hasTypeInfo() {
-		if me.LetVal != nil {
+		if me.LetVal != nil && !me.resolving {
+			me.resolving = true
 			me.copyTypeInfoFrom(me.LetVal.ExprType())
+			me.resolving = false
 		}
 	}
 	return &me.irANamedTypeRef
@@ -286,10 +293,51 @@ func (me *irAFunc) isTopLevel() (istld bool) {
 type irALitStr struct {
 	irABase
 	LitStr string
+
+	goNative bool // not a PS String but one for Go APIs such as fmt.Errorf: never subject to StringRepr
+}
+
+func (me *irALitStr) isConstable() bool { return true }
+
+func (me *irALitStr) ExprType() *irANamedTypeRef {
+	if me.RefAlias == exprTypeChar.RefAlias {
+		return exprTypeChar
+	}
+	return exprTypeStr
 }
 
-func (_ *irALitStr) ExprType() *irANamedTypeRef { return exprTypeStr }
-func (me *irALitStr) isConstable() bool         { return true }
+// isCharExpected is true if the context of this literal wants a Prim.Char: coreimp has no Char literals, only (single-code-unit) StringLiterals
+func (me *irALitStr) isCharExpected() bool {
+	switch p := me.parent.(type) {
+	case *irAOp2:
+		other := p.Left
+		if other == me {
+			other = p.Right
+		}
+		return other.ExprType().RefAlias == exprTypeChar.RefAlias
+	case *irACall:
+		if tcallee := p.Callee.ExprType(); tcallee.RefFunc != nil {
+			for i, arg := range p.CallArgs {
+				if arg == me && i < len(tcallee.RefFunc.Args) {
+					return tcallee.RefFunc.Args[i].RefAlias == exprTypeChar.RefAlias
+				}
+			}
+		}
+	case *irARet:
+		for up := p.parent; up != nil; up = up.Parent() {
+			if fn, _ := up.(*irAFunc); fn != nil {
+				return len(fn.RefFunc.Rets) > 0 && fn.RefFunc.Rets[0].RefAlias == exprTypeChar.RefAlias
+			}
+		}
+	case *irALet, *irAConst:
+		return p.ExprType().RefAlias == exprTypeChar.RefAlias
+	case *irASet:
+		return p.SetLeft.ExprType().RefAlias == exprTypeChar.RefAlias
+	case *irALitArr:
+		return p.RefArray != nil && p.RefArray.Of != nil && p.RefArray.Of.RefAlias == exprTypeChar.RefAlias
+	}
+	return false
+}
 
 func (me *irALitStr) Equiv(cmp irA) bool {
 	c, _ := cmp.(*irALitStr)
@@ -315,7 +363,7 @@ type irALitNum struct {
 }
 
 func (_ *irALitNum) ExprType() *irANamedTypeRef { return exprTypeNum }
-func (_ irALitNum) isConstable() bool           { return true }
+func (me irALitNum) isConstable() bool          { return !(math.IsInf(me.LitNum, 0) || math.IsNaN(me.LitNum)) }
 
 func (me *irALitNum) Equiv(cmp irA) bool {
 	c, _ := cmp.(*irALitNum)
@@ -467,7 +515,7 @@ func (me *irAOp2) Equiv(cmp irA) bool {
 func (me *irAOp2) ExprType() *irANamedTypeRef {
 	if !me.hasTypeInfo() {
 		switch me.Op2 {
-		case "==", "!=", "<", "<=", ">", ">=", "&&", "||", "&", "|":
+		case "==", "!=", "<", "<=", ">", ">=", "&&", "||":
 			me.copyTypeInfoFrom(exprTypeBool)
 		default:
 			if tl, tr := me.Left.ExprType(), me.Right.ExprType(); tl.hasTypeInfo() {
@@ -480,7 +528,40 @@ func (me *irAOp2) ExprType() *irANamedTypeRef {
 	return &me.irANamedTypeRef
 }
 
+// goIntrinsic returns the name of the func in gonad's 𝒈 package that
+// implements this operator where Go's own semantics differ from PureScript's
+// (division by zero, Euclidean div/mod, 32-bit shifts and the missing `>>>`).
+// For `/` and `%`, that's only decided by an operand known to be an Int or a
+// Number: with neither (yet) known, Go's own operator is kept as-is, since
+// the Int intrinsics wouldn't compile for (or would truncate) Numbers.
+func (me *irAOp2) goIntrinsic() string {
+	switch me.Op2 {
+	case "<<":
+		return "IntShl"
+	case ">>":
+		return "IntShr"
+	case ">>>":
+		return "IntZshr"
+	case "/", "%":
+		tl, tr := me.Left.ExprType(), me.Right.ExprType()
+		if tl.RefAlias == exprTypeNum.RefAlias || tr.RefAlias == exprTypeNum.RefAlias {
+			if me.Op2 == "%" {
+				return "NumMod"
+			}
+		} else if tl.RefAlias == exprTypeInt.RefAlias || tr.RefAlias == exprTypeInt.RefAlias {
+			if me.Op2 == "/" {
+				return "IntDiv"
+			}
+			return "IntMod"
+		}
+	}
+	return ""
+}
+
 func (me irAOp2) isConstable() bool {
+	if me.goIntrinsic() != "" {
+		return false
+	}
 	if cl, _ := me.Left.(irAConstable); cl != nil && cl.isConstable() {
 		if cr, _ := me.Right.(irAConstable); cr != nil && cr.isConstable() {
 			return true
@@ -658,6 +739,39 @@ func (me *irAPanic) Equiv(cmp irA) bool {
 	return me.Base().Equiv(c) && (c == nil || me.PanicArg.Equiv(c.PanicArg))
 }
 
+// isErrCtor is true if PanicArg already constructs a typed gonad error value, as lowered from `new Error(..)` in postFixupAmpCtor.
+func (me *irAPanic) isErrCtor() bool {
+	if _, ok := me.PanicArg.(*irAPatMatchFail); ok {
+		return true
+	} else if call, _ := me.PanicArg.(*irACall); call != nil {
+		if pkgsym, _ := call.Callee.(*irAPkgSym); pkgsym != nil {
+			return pkgsym.PkgName == "𝒈" && (pkgsym.Symbol == "Err" || pkgsym.Symbol == "Errf")
+		}
+	}
+	return false
+}
+
+// irAPatMatchFail is coreimp's `new Error("Failed pattern match at ..: " + [..])`, lowered to a *𝒈.PatternMatchFailure.
+type irAPatMatchFail struct {
+	irABase
+	ModQName   string
+	SrcPos     [4]int // start line, start column, end line, end column
+	Scrutinees []irA
+}
+
+func (me *irAPatMatchFail) Equiv(cmp irA) bool {
+	c, _ := cmp.(*irAPatMatchFail)
+	if me != nil && c != nil && me.ModQName == c.ModQName && me.SrcPos == c.SrcPos && len(me.Scrutinees) == len(c.Scrutinees) {
+		for i, v := range me.Scrutinees {
+			if !v.Equiv(c.Scrutinees[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return me == nil && c == nil
+}
+
 type irALitArr struct {
 	irABase
 	ArrVals []irA
@@ -758,9 +872,11 @@ func (me *irAPkgSym) Equiv(cmp irA) bool {
 
 func (me *irAPkgSym) ExprType() *irANamedTypeRef {
 	if !me.hasTypeInfo() {
-		if mod := findModuleByPName(me.PkgName); mod != nil {
-			if ref := mod.irMeta.goValDeclByGoName(me.Symbol); ref != nil {
-				me.copyTypeInfoFrom(ref)
+		if ast := me.Ast(); ast != nil {
+			if mod := ast.mod.findModuleByPName(me.PkgName); mod != nil {
+				if ref := mod.irMeta.goValDeclByGoName(me.Symbol); ref != nil {
+					me.copyTypeInfoFrom(ref)
+				}
 			}
 		}
 		if !me.hasTypeInfo() {
@@ -783,12 +899,6 @@ func (me *irAst) typeCtorFunc(nameps string) *irACtor {
 	return nil
 }
 
-func (me *irAst) writeAsJsonTo(w io.Writer) error {
-	jsonenc := json.NewEncoder(w)
-	jsonenc.SetIndent("", "\t")
-	return jsonenc.Encode(me)
-}
-
 func (me *irAst) writeAsGoTo(writer io.Writer) (err error) {
 	var buf = &bytes.Buffer{}
 
@@ -799,9 +909,19 @@ func (me *irAst) writeAsGoTo(writer io.Writer) (err error) {
 	}
 
 	toplevelconsts := me.topLevelDefs(func(a irA) bool { ac, _ := a.(*irAConst); return ac != nil })
-	toplevelvars := me.topLevelDefs(func(a irA) bool { al, _ := a.(*irALet); return al != nil })
+	deferredvars := me.topLevelDeferredLets()
+	toplevelvars := me.topLevelDefs(func(a irA) bool {
+		al, _ := a.(*irALet)
+		for _, dv := range deferredvars {
+			if dv == al {
+				return false
+			}
+		}
+		return al != nil
+	})
 	me.codeGenGroupedVals(buf, true, toplevelconsts)
 	me.codeGenGroupedVals(buf, false, toplevelvars)
+	me.codeGenDeferredVals(buf, deferredvars)
 
 	toplevelfuncs := me.topLevelDefs(func(a irA) bool { af, _ := a.(*irAFunc); return af != nil })
 	for _, ast := range toplevelfuncs {
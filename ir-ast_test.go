@@ -0,0 +1,90 @@
+package gonad
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestOp2GoIntrinsic(t *testing.T) {
+	num := ªSymGo("n")
+	num.RefAlias = exprTypeNum.RefAlias
+	integer := ªSymGo("i")
+	integer.RefAlias = exprTypeInt.RefAlias
+	for _, tc := range []struct {
+		op2  *irAOp2
+		want string
+	}{
+		{ªO2(ªI(7), "/", ªI(2)), "IntDiv"},
+		{ªO2(ªSymGo("x"), "/", ªSymGo("y")), ""}, // untyped operands keep Go's own operator
+		{ªO2(ªSymGo("x"), "%", ªSymGo("y")), ""},
+		{ªO2(ªSymGo("x"), "%", ªI(2)), "IntMod"},
+		{ªO2(integer, "/", ªSymGo("y")), "IntDiv"},
+		{ªO2(ªN(7), "/", ªSymGo("y")), ""},
+		{ªO2(ªSymGo("x"), "/", num), ""},
+		{ªO2(ªSymGo("x"), "%", num), "NumMod"},
+		{ªO2(ªI(1), "<<", ªI(33)), "IntShl"},
+		{ªO2(ªI(1), "*", ªI(3)), ""},
+	} {
+		if got := tc.op2.goIntrinsic(); got != tc.want {
+			t.Errorf("%s %s %s: want %q, got %q", tc.op2.Left.ExprType().RefAlias, tc.op2.Op2, tc.op2.Right.ExprType().RefAlias, tc.want, got)
+		}
+	}
+}
+
+func TestLitStrChar(t *testing.T) {
+	for strrepr, wants := range map[string][]string{
+		strReprUtf8:  {"c == 'a'", `s == "a"`, "f('\\n')", `g("\n")`},
+		strReprUtf16: {"c == 𝒈.Char(0x0061)", `s == 𝒈.Str("\x00a")`, "f(𝒈.Char(0x000a))", `g(𝒈.Str("\x00\n"))`},
+	} {
+		sess := newSession(Options{})
+		sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr = strrepr
+		mod := &modPkg{qName: "My.Mod", proj: &sess.proj}
+		mod.irMeta = &irMeta{mod: mod, proj: &sess.proj}
+		irast := &irAst{mod: mod, irM: mod.irMeta}
+		sym := func(name string, tref *irANamedTypeRef) *irASym {
+			a := ªSymGo(name)
+			a.copyTypeInfoFrom(tref)
+			return a
+		}
+		fn := func(name string, arg *irANamedTypeRef) *irASym {
+			return sym(name, &irANamedTypeRef{RefFunc: &irATypeRefFunc{Args: irANamedTypeRefs{arg}, Rets: irANamedTypeRefs{exprTypeBool}}})
+		}
+		exprs := []irA{ªO2(sym("c", exprTypeChar), "==", ªS("a")), ªO2(sym("s", exprTypeStr), "==", ªS("a")), ªCall(fn("f", exprTypeChar), ªS("\n")), ªCall(fn("g", exprTypeStr), ªS("\n"))}
+		irast.add(exprs...)
+		irast.postFinalFixups()
+		for i, expr := range exprs {
+			var buf bytes.Buffer
+			if irast.codeGenAst(&buf, 0, expr); buf.String() != wants[i] {
+				t.Errorf("%s: want %s, got %s", strrepr, wants[i], buf.String())
+			}
+		}
+	}
+}
+
+func TestLitNumInt(t *testing.T) {
+	sess := newSession(Options{})
+	mod := &modPkg{qName: "My.Mod", proj: &sess.proj}
+	mod.irMeta = &irMeta{mod: mod, proj: &sess.proj}
+	irast := &irAst{mod: mod, irM: mod.irMeta}
+	for lit, want := range map[irA]string{
+		ªN(0.1):                  "0.1",
+		ªN(3):                    "3.0",
+		ªN(1e21):                 "1e+21",
+		ªN(math.Copysign(0, -1)): "math.Copysign(0, -1)",
+		ªN(math.Inf(-1)):         "math.Inf(-1)",
+		ªN(math.NaN()):           "math.NaN()",
+		ªI(math.MinInt32):        "-2147483648",
+	} {
+		var buf bytes.Buffer
+		if irast.codeGenAst(&buf, 0, lit); buf.String() != want {
+			t.Errorf("want %s, got %s", want, buf.String())
+		}
+	}
+	defer func() {
+		if err, _ := recover().(*notImplError); err == nil {
+			t.Error("no notImplErr for an out-of-range Int literal")
+		}
+	}()
+	irast.codeGenAst(&bytes.Buffer{}, 0, ªI(math.MaxInt32+1))
+}
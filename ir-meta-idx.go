@@ -0,0 +1,123 @@
+package gonad
+
+import (
+	"sync"
+)
+
+/*
+Hashed lookups into an irMeta's (and the whole project's)
+otherwise linearly-scanned slices, as these lookups happen
+all over per-node walks. The few writers of those slices
+(the populate* funcs, mostly) call idxInvalidate after any
+change, and the index gets (re)built on the next lookup.
+*/
+
+type irMetaIdx struct {
+	sync.RWMutex
+	dirty bool // set by idxInvalidate, or not yet built at all if exports == nil
+
+	exports        map[string]bool
+	foreignVals    map[string]bool
+	tcs            map[string]*irMTypeClass
+	tcMembers      map[string]*irMTypeClassMember
+	tcInsts        map[string]*irMTypeClassInst
+	typeDataDecls  map[string]*irMTypeDataDecl
+	goTypeDefsByGo map[string]*irANamedTypeRef
+	goTypeDefsByPs map[string]*irANamedTypeRef
+	goValDeclsByGo map[string]*irANamedTypeRef
+	goValDeclsByPs map[string]*irANamedTypeRef
+}
+
+// idxInvalidate must be called after any change to the slices indexed in irMetaIdx
+func (me *irMeta) idxInvalidate() {
+	me.idx.Lock()
+	me.idx.dirty = true
+	me.idx.Unlock()
+}
+
+// lookup runs fn against our up-to-date irMetaIdx
+func (me *irMeta) lookup(fn func(*irMetaIdx)) {
+	idx := &me.idx
+	idx.RLock()
+	if !(idx.dirty || idx.exports == nil) {
+		defer idx.RUnlock()
+		fn(idx)
+		return
+	}
+	idx.RUnlock()
+	idx.Lock()
+	defer idx.Unlock()
+	if idx.dirty || idx.exports == nil {
+		idx.rebuild(me)
+	}
+	fn(idx)
+}
+
+func (me *irMetaIdx) rebuild(irM *irMeta) {
+	me.dirty = false
+	me.exports, me.foreignVals = make(map[string]bool, len(irM.Exports)), make(map[string]bool, len(irM.EnvForeignVals))
+	for _, name := range irM.Exports {
+		me.exports[name] = true
+	}
+	for _, name := range irM.EnvForeignVals {
+		me.foreignVals[name] = true
+	}
+	me.tcs, me.tcMembers = make(map[string]*irMTypeClass, len(irM.EnvTypeClasses)), map[string]*irMTypeClassMember{}
+	for _, tc := range irM.EnvTypeClasses {
+		if me.tcs[tc.Name] == nil {
+			me.tcs[tc.Name] = tc
+		}
+		for _, tcm := range tc.Members {
+			if me.tcMembers[tcm.Name] == nil {
+				me.tcMembers[tcm.Name] = tcm
+			}
+		}
+	}
+	me.tcInsts = make(map[string]*irMTypeClassInst, len(irM.EnvTypeClassInsts))
+	for _, tci := range irM.EnvTypeClassInsts {
+		if me.tcInsts[tci.Name] == nil {
+			me.tcInsts[tci.Name] = tci
+		}
+	}
+	me.typeDataDecls = make(map[string]*irMTypeDataDecl, len(irM.EnvTypeDataDecls))
+	for _, tdd := range irM.EnvTypeDataDecls {
+		if me.typeDataDecls[tdd.Name] == nil {
+			me.typeDataDecls[tdd.Name] = tdd
+		}
+	}
+	me.goTypeDefsByGo, me.goTypeDefsByPs = make(map[string]*irANamedTypeRef, len(irM.GoTypeDefs)), make(map[string]*irANamedTypeRef, len(irM.GoTypeDefs))
+	for _, gtd := range irM.GoTypeDefs {
+		if me.goTypeDefsByGo[gtd.NameGo] == nil {
+			me.goTypeDefsByGo[gtd.NameGo] = gtd
+		}
+		// by PS name, the first non-interface one wins, or else the last interface one
+		if cur := me.goTypeDefsByPs[gtd.NamePs]; cur == nil || cur.RefInterface != nil {
+			me.goTypeDefsByPs[gtd.NamePs] = gtd
+		}
+	}
+	me.goValDeclsByGo, me.goValDeclsByPs = make(map[string]*irANamedTypeRef, len(irM.GoValDecls)), make(map[string]*irANamedTypeRef, len(irM.GoValDecls))
+	for _, gvd := range irM.GoValDecls {
+		if me.goValDeclsByGo[gvd.NameGo] == nil {
+			me.goValDeclsByGo[gvd.NameGo] = gvd
+		}
+		if me.goValDeclsByPs[gvd.NamePs] == nil {
+			me.goValDeclsByPs[gvd.NamePs] = gvd
+		}
+	}
+}
+
+// indexModPkgs sets up modPkgIdx, with modules of the main proj winning over same-named ones in deps (like the former scans did)
+func (me *session) indexModPkgs() {
+	me.modPkgIdx.byQName, me.modPkgIdx.byPName = map[string]*modPkg{}, map[string]*modPkg{}
+	add := func(dep *psBowerProject) {
+		for _, m := range dep.Modules {
+			me.modPkgIdx.byQName[m.qName], me.modPkgIdx.byPName[m.pName] = m, m
+		}
+	}
+	for depname, dep := range me.deps {
+		if depname != "" && dep != &me.proj {
+			add(dep)
+		}
+	}
+	add(&me.proj)
+}
@@ -0,0 +1,119 @@
+package gonad
+
+import (
+	"fmt"
+	"testing"
+)
+
+const benchNumMods, benchNumDecls = 800, 400
+
+func benchIrMeta() *irMeta {
+	irM := &irMeta{}
+	for i := 0; i < benchNumDecls; i++ {
+		gtd, gvd := &irANamedTypeRef{}, &irANamedTypeRef{}
+		gtd.setBothNamesFromPsName(fmt.Sprintf("Type%d", i))
+		gvd.setBothNamesFromPsName(fmt.Sprintf("val%d", i))
+		irM.GoTypeDefs, irM.GoValDecls, irM.Exports = append(irM.GoTypeDefs, gtd), append(irM.GoValDecls, gvd), append(irM.Exports, gtd.NamePs, gvd.NamePs)
+	}
+	return irM
+}
+
+func benchModPkgs() (sess *session) {
+	sess = newSession(Options{})
+	sess.deps[""] = &sess.proj
+	for i := 0; i < benchNumMods; i++ {
+		qname := fmt.Sprintf("Data.Mod%d", i)
+		sess.proj.Modules = append(sess.proj.Modules, &modPkg{qName: qname, pName: strReplDot2ꓸ.Replace(qname), proj: &sess.proj})
+	}
+	return
+}
+
+func BenchmarkIrMetaLookups(b *testing.B) {
+	irM := benchIrMeta()
+	b.Run("goTypeDefByPsName/linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			name := irM.GoTypeDefs[i%benchNumDecls].NamePs
+			for _, gtd := range irM.GoTypeDefs {
+				if gtd.NamePs == name {
+					break
+				}
+			}
+		}
+	})
+	b.Run("goTypeDefByPsName/indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if irM.goTypeDefByPsName(irM.GoTypeDefs[i%benchNumDecls].NamePs) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("hasExport/linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			name := irM.GoValDecls[i%benchNumDecls].NamePs
+			for _, exp := range irM.Exports {
+				if exp == name {
+					break
+				}
+			}
+		}
+	})
+	b.Run("hasExport/indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if !irM.hasExport(irM.GoValDecls[i%benchNumDecls].NamePs) {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
+
+func BenchmarkFindModuleByQName(b *testing.B) {
+	sess := benchModPkgs()
+	b.Run("linear", func(b *testing.B) {
+		sess.modPkgIdx.byQName, sess.modPkgIdx.byPName = nil, nil
+		for i := 0; i < b.N; i++ {
+			if sess.findModuleByQName(sess.proj.Modules[i%benchNumMods].qName) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("indexed", func(b *testing.B) {
+		sess.indexModPkgs()
+		for i := 0; i < b.N; i++ {
+			if sess.findModuleByQName(sess.proj.Modules[i%benchNumMods].qName) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
+
+// the indexes must keep up with the slices changing (by their writers' idxInvalidate) after lookups already happened
+func TestIrMetaIdxInvalidate(t *testing.T) {
+	irM := benchIrMeta()
+	if irM.goValDeclByGoName("late") != nil || irM.hasExport("late") {
+		t.Fatal("found before added")
+	}
+	gvd := &irANamedTypeRef{}
+	gvd.NameGo, gvd.NamePs = "late", "late"
+	irM.GoValDecls, irM.Exports = append(irM.GoValDecls, gvd), append(irM.Exports, "late")
+	irM.idxInvalidate()
+	if irM.goValDeclByGoName("late") != gvd || !irM.hasExport("late") {
+		t.Fatal("not found after added")
+	}
+	iface, strct := &irANamedTypeRef{NamePs: "T", RefInterface: &irATypeRefInterface{}}, &irANamedTypeRef{NamePs: "T", RefStruct: &irATypeRefStruct{}}
+	irM.GoTypeDefs = append(irM.GoTypeDefs, iface)
+	irM.idxInvalidate()
+	if irM.goTypeDefByPsName("T") != iface {
+		t.Fatal("interface type-def not found")
+	}
+	irM.GoTypeDefs = append(irM.GoTypeDefs, strct)
+	irM.idxInvalidate()
+	if irM.goTypeDefByPsName("T") != strct {
+		t.Fatal("non-interface type-def not preferred")
+	}
+	// replaced in place, so same lengths as before
+	irM.GoTypeDefs[len(irM.GoTypeDefs)-1], irM.Exports[len(irM.Exports)-1] = iface, "later"
+	irM.idxInvalidate()
+	if irM.goTypeDefByPsName("T") != iface || irM.hasExport("late") || !irM.hasExport("later") {
+		t.Fatal("stale after in-place replacement")
+	}
+}
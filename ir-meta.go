@@ -1,14 +1,21 @@
-package main
+package gonad
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/metaleap/go-util/dev/ps"
 	"github.com/metaleap/go-util/fs"
-	"github.com/metaleap/go-util/slice"
 	"github.com/metaleap/go-util/str"
 )
 
@@ -40,7 +47,29 @@ format can be readily-deserialized without needing to
 reprocess/reinterpret the original raw source coreimp.
 */
 
+// bump whenever the shape of gonad.json (or of anything it holds) changes incompatibly
+const irMetaVersion = 2 // 2: default FFI import paths moved from github.com/gonadz/- to github.com/gonadz/g
+
+const (
+	gonadModPath      = "github.com/metaleap/gonad"
+	gonadBuildExeHash = "exehash" // as the gonadBuild, means a hash of the executable (that of whichever program embeds us): any re-build of it then makes all gonad.jsons stale
+)
+
+var (
+	// settable via -ldflags "-X github.com/metaleap/gonad.gonadBuild=..." (eg. to a VCS revision, or gonadBuildExeHash), otherwise see gonadBuildOfBuildInfo and gonadBuildOfExe
+	gonadBuild     string
+	gonadBuildOnce sync.Once
+)
+
+// irMetaStamp records which gonad (with which relevant Gonad.CodeGen settings) wrote a gonad.json: any mismatch makes it (and all its importers) stale
+type irMetaStamp struct {
+	Version int
+	Build   string
+	CodeGen string
+}
+
 type irMeta struct {
+	Gonad             irMetaStamp
 	Exports           []string            `json:",omitempty"`
 	Imports           irMPkgRefs          `json:",omitempty"`
 	EnvTypeSyns       []*irMNamedTypeRef  `json:",omitempty"`
@@ -48,11 +77,13 @@ type irMeta struct {
 	EnvTypeClassInsts []*irMTypeClassInst `json:",omitempty"`
 	EnvTypeDataDecls  []*irMTypeDataDecl  `json:",omitempty"`
 	EnvValDecls       []*irMNamedTypeRef  `json:",omitempty"`
+	EnvForeignVals    []string            `json:",omitempty"`
 	GoTypeDefs        irANamedTypeRefs    `json:",omitempty"`
 	GoValDecls        irANamedTypeRefs    `json:",omitempty"`
 	ForeignImp        *irMPkgRef          `json:",omitempty"`
 
 	imports []*modPkg
+	idx     irMetaIdx
 
 	mod     *modPkg
 	proj    *psBowerProject
@@ -72,7 +103,9 @@ func (me irMPkgRefs) Swap(i, j int) { me[i], me[j] = me[j], me[i] }
 
 func (me *irMPkgRefs) addIfMissing(lname, imppath, qname string) (pkgref *irMPkgRef, added bool) {
 	if imppath == "" {
-		if strings.HasPrefix(lname, prefixDefaultFfiPkgNs) {
+		if lname == "𝒈" {
+			imppath, lname = impPathDefaultFfiRoot, ""
+		} else if strings.HasPrefix(lname, prefixDefaultFfiPkgNs) {
 			imppath = prefixDefaultFfiPkgImpPath + strReplˈ2Slash.Replace(lname[len(prefixDefaultFfiPkgNs):])
 			lname, qname = "", ""
 		} else {
@@ -263,9 +296,9 @@ func (me *irMeta) ensureImp(lname, imppath, qname string) *irMPkgRef {
 	if imppath == "" && (ustr.BeginsUpper(lname) || ustr.BeginsUpper(qname)) {
 		var mod *modPkg
 		if qname != "" {
-			mod = findModuleByQName(qname)
+			mod = me.mod.findModuleByQName(qname)
 		} else if lname != "" {
-			mod = findModuleByPName(lname)
+			mod = me.mod.findModuleByPName(lname)
 		}
 		if mod != nil {
 			lname, qname, imppath = mod.pName, mod.qName, mod.impPath()
@@ -278,37 +311,42 @@ func (me *irMeta) ensureImp(lname, imppath, qname string) *irMPkgRef {
 	return imp
 }
 
-func (me *irMeta) hasExport(name string) bool {
-	return uslice.StrHas(me.Exports, name)
+func (me *irMeta) hasExport(name string) (has bool) {
+	me.lookup(func(idx *irMetaIdx) { has = idx.exports[name] })
+	return
 }
 
-func (me *irMeta) tc(name string) *irMTypeClass {
-	for _, tc := range me.EnvTypeClasses {
-		if tc.Name == name {
-			return tc
-		}
-	}
-	return nil
+func (me *irMeta) tc(name string) (tc *irMTypeClass) {
+	me.lookup(func(idx *irMetaIdx) { tc = idx.tcs[name] })
+	return
 }
 
-func (me *irMeta) tcInst(name string) *irMTypeClassInst {
-	for _, tci := range me.EnvTypeClassInsts {
-		if tci.Name == name {
-			return tci
-		}
-	}
-	return nil
+func (me *irMeta) typeDataDecl(name string) (tdd *irMTypeDataDecl) {
+	me.lookup(func(idx *irMetaIdx) { tdd = idx.typeDataDecls[name] })
+	return
 }
 
-func (me *irMeta) tcMember(name string) *irMTypeClassMember {
-	for _, tc := range me.EnvTypeClasses {
-		for _, tcm := range tc.Members {
-			if tcm.Name == name {
-				return tcm
-			}
+// definesType is true for our data types and type synonyms, but not for our type-classes (despite their synonyms)
+func (me *irMeta) definesType(name string) bool {
+	if me.typeDataDecl(name) != nil {
+		return true
+	}
+	for _, ts := range me.EnvTypeSyns {
+		if ts.Name == name {
+			return me.tc(name) == nil
 		}
 	}
-	return nil
+	return false
+}
+
+func (me *irMeta) tcInst(name string) (tci *irMTypeClassInst) {
+	me.lookup(func(idx *irMetaIdx) { tci = idx.tcInsts[name] })
+	return
+}
+
+func (me *irMeta) tcMember(name string) (tcm *irMTypeClassMember) {
+	me.lookup(func(idx *irMetaIdx) { tcm = idx.tcMembers[name] })
+	return
 }
 
 func (me *irMeta) newTypeRefFromEnvTag(tc *udevps.CoreTagType) (tref *irMTypeRef) {
@@ -345,18 +383,49 @@ func (me *irMeta) newTypeRefFromEnvTag(tc *udevps.CoreTagType) (tref *irMTypeRef
 }
 
 func (me *irMeta) populateEnvFuncsAndVals() {
-	for fname, fdef := range me.mod.coreimp.DeclEnv.Functions {
+	fnames := make([]string, 0, len(me.mod.coreimp.DeclEnv.Functions))
+	for fname := range me.mod.coreimp.DeclEnv.Functions {
+		fnames = append(fnames, fname)
+	}
+	sort.Strings(fnames) // here and in the other populateEnv* funcs: DeclEnv's maps in key order, so that our outputs don't vary from run to run
+	for _, fname := range fnames {
+		fdef := me.mod.coreimp.DeclEnv.Functions[fname]
 		me.EnvValDecls = append(me.EnvValDecls, &irMNamedTypeRef{Name: fname, Ref: me.newTypeRefFromEnvTag(fdef.Type)})
 	}
+	if me.mod.coreimp.My.NamedRequires["$foreign"] != "" {
+		//	foreign imports are those env vals that never get defined in the module body
+		topleveldefs := map[string]bool{}
+		for _, cia := range me.mod.coreimp.Body {
+			if cia.AstTag == "VariableIntroduction" {
+				topleveldefs[cia.VariableIntroduction] = true
+			} else if cia.AstTag == "Function" {
+				topleveldefs[cia.Function] = true
+			}
+		}
+		for _, evd := range me.EnvValDecls {
+			if !topleveldefs[evd.Name] {
+				me.EnvForeignVals = append(me.EnvForeignVals, evd.Name)
+			}
+		}
+		sort.Strings(me.EnvForeignVals)
+		me.idxInvalidate()
+	}
 }
 
 func (me *irMeta) populateEnvTypeDataDecls() {
-	for tdefname, tdef := range me.mod.coreimp.DeclEnv.TypeDefs {
+	tdefnames := make([]string, 0, len(me.mod.coreimp.DeclEnv.TypeDefs))
+	for tdefname := range me.mod.coreimp.DeclEnv.TypeDefs {
+		tdefnames = append(tdefnames, tdefname)
+	}
+	sort.Strings(tdefnames)
+	for _, tdefname := range tdefnames {
+		tdef := me.mod.coreimp.DeclEnv.TypeDefs[tdefname]
 		if tdef.Decl.TypeSynonym {
 			//	type-aliases handled separately in populateEnvTypeSyns already, nothing to do here
 		} else if tdef.Decl.ExternData {
-			if ffigofilepath := me.mod.srcFilePath[:len(me.mod.srcFilePath)-len(".purs")] + ".go"; ufs.FileExists(ffigofilepath) {
-				panic(me.mod.srcFilePath + ": time to handle FFI " + ffigofilepath)
+			if len(me.mod.ffiFilePaths) > 0 {
+				//	user-supplied FFI: the type gets declared there (right in our package, see ffi.go), so a ctor-less data decl that generates no Go type-def
+				me.EnvTypeDataDecls = append(me.EnvTypeDataDecls, &irMTypeDataDecl{Name: tdefname})
 			} else {
 				//	special case for official purescript core libs: alias to applicable struct from gonad's default ffi packages
 				ta := &irMNamedTypeRef{Name: tdefname, Ref: &irMTypeRef{TypeConstructor: prefixDefaultFfiPkgNs + strReplDot2ˈ.Replace(me.mod.qName) + "." + tdefname}}
@@ -377,18 +446,30 @@ func (me *irMeta) populateEnvTypeDataDecls() {
 			me.EnvTypeDataDecls = append(me.EnvTypeDataDecls, dt)
 		}
 	}
+	me.idxInvalidate()
 }
 
 func (me *irMeta) populateEnvTypeSyns() {
-	for tsname, tsdef := range me.mod.coreimp.DeclEnv.TypeSyns {
+	tsnames := make([]string, 0, len(me.mod.coreimp.DeclEnv.TypeSyns))
+	for tsname := range me.mod.coreimp.DeclEnv.TypeSyns {
+		tsnames = append(tsnames, tsname)
+	}
+	sort.Strings(tsnames)
+	for _, tsname := range tsnames {
 		ts := &irMNamedTypeRef{Name: tsname}
-		ts.Ref = me.newTypeRefFromEnvTag(tsdef.Type)
+		ts.Ref = me.newTypeRefFromEnvTag(me.mod.coreimp.DeclEnv.TypeSyns[tsname].Type)
 		me.EnvTypeSyns = append(me.EnvTypeSyns, ts)
 	}
 }
 
 func (me *irMeta) populateEnvTypeClasses() {
-	for tcname, tcdef := range me.mod.coreimp.DeclEnv.Classes {
+	tcnames := make([]string, 0, len(me.mod.coreimp.DeclEnv.Classes))
+	for tcname := range me.mod.coreimp.DeclEnv.Classes {
+		tcnames = append(tcnames, tcname)
+	}
+	sort.Strings(tcnames)
+	for _, tcname := range tcnames {
+		tcdef := me.mod.coreimp.DeclEnv.Classes[tcname]
 		tc := &irMTypeClass{Name: tcname}
 		for _, tcarg := range tcdef.Args {
 			tc.Args = append(tc.Args, tcarg.Name)
@@ -407,8 +488,19 @@ func (me *irMeta) populateEnvTypeClasses() {
 		me.EnvTypeClasses = append(me.EnvTypeClasses, tc)
 	}
 	for _, m := range me.mod.coreimp.DeclEnv.ClassDicts {
-		for tciclass, tcinsts := range m {
-			for tciname, tcidef := range tcinsts {
+		tciclasses := make([]string, 0, len(m))
+		for tciclass := range m {
+			tciclasses = append(tciclasses, tciclass)
+		}
+		sort.Strings(tciclasses)
+		for _, tciclass := range tciclasses {
+			tcinsts, tcinames := m[tciclass], make([]string, 0, len(m[tciclass]))
+			for tciname := range tcinsts {
+				tcinames = append(tcinames, tciname)
+			}
+			sort.Strings(tcinames)
+			for _, tciname := range tcinames {
+				tcidef := tcinsts[tciname]
 				tci := &irMTypeClassInst{Name: tciname, ClassName: tciclass}
 				for _, tcit := range tcidef.InstanceTypes {
 					tci.InstTypes = append(tci.InstTypes, me.newTypeRefFromEnvTag(tcit))
@@ -417,6 +509,7 @@ func (me *irMeta) populateEnvTypeClasses() {
 			}
 		}
 	}
+	me.idxInvalidate()
 }
 
 func (me *irMeta) populateFromCoreImp() {
@@ -428,6 +521,7 @@ func (me *irMeta) populateFromCoreImp() {
 			me.Exports = append(me.Exports, tname)
 			if len(exp.TypeRef) > 2 {
 				if ctornames, _ := exp.TypeRef[2].([]interface{}); len(ctornames) > 0 {
+					me.idxInvalidate() // for hasExport to see the Exports so far
 					for _, ctorname := range ctornames {
 						if cn, _ := ctorname.(string); cn != "" && !me.hasExport(cn) {
 							me.Exports = append(me.Exports, tname+"ĸ"+cn)
@@ -449,10 +543,11 @@ func (me *irMeta) populateFromCoreImp() {
 			me.Exports = append(me.Exports, exp.TypeInstanceRef[1].(map[string]interface{})["Ident"].(string))
 		}
 	}
+	me.idxInvalidate()
 	// discover and store imports
 	for _, imp := range me.mod.coreimp.Imps {
 		if impname := strings.Join(imp, "."); impname != "Prim" && impname != "Prelude" && impname != me.mod.qName {
-			me.imports = append(me.imports, findModuleByQName(impname))
+			me.imports = append(me.imports, me.mod.findModuleByQName(impname))
 		}
 	}
 	for _, impmod := range me.imports {
@@ -470,10 +565,16 @@ func (me *irMeta) populateFromCoreImp() {
 }
 
 func (me *irMeta) populateFromLoaded() {
+	me.idxInvalidate() // the gonad.json might have been unmarshaled into an irMeta indexed before
+	for _, tc := range me.EnvTypeClasses {
+		for _, tcm := range tc.Members {
+			tcm.tc = tc // not in the gonad.json, unlike all else populateFromCoreImp sets up
+		}
+	}
 	me.imports = nil
 	for _, imp := range me.Imports {
 		if !strings.HasPrefix(imp.ImpPath, prefixDefaultFfiPkgImpPath) {
-			if impmod := findModuleByQName(imp.PsModQName); impmod != nil {
+			if impmod := me.mod.proj.sess.modPkgByQName(imp.PsModQName); impmod != nil { // not findModuleByQName: no need to load its irMeta (yet)
 				me.imports = append(me.imports, impmod)
 			} else if imp.PsModQName != "" {
 				panic(fmt.Errorf("%s: bad import %s", me.mod.srcFilePath, imp.PsModQName))
@@ -503,28 +604,122 @@ func (me *irMeta) populateGoValDecls() {
 			}
 		}
 		me.GoValDecls = append(me.GoValDecls, gvd)
+		me.idxInvalidate()
 	}
 }
 
-func (me *irMeta) goValDeclByGoName(goname string) *irANamedTypeRef {
-	for _, gvd := range me.GoValDecls {
-		if gvd.NameGo == goname {
-			return gvd
+func (me *irMeta) goValDeclByGoName(goname string) (gvd *irANamedTypeRef) {
+	me.lookup(func(idx *irMetaIdx) { gvd = idx.goValDeclsByGo[goname] })
+	return
+}
+
+func (me *irMeta) goValDeclByPsName(psname string) (gvd *irANamedTypeRef) {
+	me.lookup(func(idx *irMetaIdx) { gvd = idx.goValDeclsByPs[psname] })
+	return
+}
+
+func (me *irMeta) isForeignVal(psname string) (is bool) {
+	me.lookup(func(idx *irMetaIdx) { is = idx.foreignVals[psname] })
+	return
+}
+
+func curGonadBuild() string {
+	gonadBuildOnce.Do(func() {
+		if gonadBuild == "" {
+			info, _ := debug.ReadBuildInfo()
+			gonadBuild = gonadBuildOfBuildInfo(info)
+		}
+		if gonadBuild == gonadBuildExeHash {
+			gonadBuild = gonadBuildOfExe()
+		}
+	})
+	return gonadBuild
+}
+
+// gonadBuildOfBuildInfo is our module version (or, when we're the main module, the VCS revision) as recorded by the go tool. Builds without either (such as all GOPATH-mode builds) get gonadBuildExeHash instead
+func gonadBuildOfBuildInfo(info *debug.BuildInfo) string {
+	if info != nil {
+		if info.Main.Path == gonadModPath {
+			var revision, modified string
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					revision = setting.Value
+				} else if setting.Key == "vcs.modified" && setting.Value == "true" {
+					modified = "+modified"
+				}
+			}
+			if revision != "" {
+				return revision + modified
+			} else if info.Main.Version != "" && info.Main.Version != "(devel)" {
+				return info.Main.Version
+			}
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == gonadModPath && dep.Replace == nil && dep.Version != "(devel)" {
+				return dep.Version
+			}
 		}
 	}
-	return nil
+	return gonadBuildExeHash
 }
 
-func (me *irMeta) goValDeclByPsName(psname string) *irANamedTypeRef {
-	for _, gvd := range me.GoValDecls {
-		if gvd.NamePs == psname {
-			return gvd
+// gonadBuildOfExe hashes the executable, or is "dev" if that can't be read
+func gonadBuildOfExe() string {
+	if exepath, err := os.Executable(); err == nil {
+		if exebytes, err := ioutil.ReadFile(exepath); err == nil {
+			exehash := sha1.Sum(exebytes)
+			return hex.EncodeToString(exehash[:8])
 		}
 	}
-	return nil
+	return "dev"
+}
+
+func (me *session) curIrMetaStamp() irMetaStamp {
+	cfg := &me.proj.BowerJsonFile.Gonad.CodeGen
+	passnames := make([]string, 0, len(me.irPassPipeline))
+	for _, pass := range me.irPassPipeline {
+		passnames = append(passnames, pass.name)
+	}
+	return irMetaStamp{Version: irMetaVersion, Build: me.flag.Build,
+		CodeGen: fmt.Sprintf("PtrStructMinFieldCount=%d StringRepr=%s Passes=%s", cfg.PtrStructMinFieldCount, cfg.StringRepr, strings.Join(passnames, ","))}
+}
+
+// staleness describes why a gonad.json so stamped must not be used anymore, or returns "" if it's current
+func (me *irMetaStamp) staleness(sess *session) string {
+	cur := sess.curIrMetaStamp()
+	switch {
+	case me.Version != cur.Version:
+		return fmt.Sprintf("written in gonad.json format version %d, now %d", me.Version, cur.Version)
+	case me.Build != cur.Build:
+		return fmt.Sprintf("written by gonad build %s, now %s", me.Build, cur.Build)
+	case me.CodeGen != cur.CodeGen:
+		return fmt.Sprintf("written with Gonad.CodeGen settings `%s`, now `%s`", me.CodeGen, cur.CodeGen)
+	}
+	return ""
+}
+
+// the gonad.stamp.json next to all the modules' gonad.json dirs records the irMetaStamp of the last complete run
+func (me *session) irMetaStampFilePath() string {
+	return filepath.Join(me.proj.BowerJsonFile.Gonad.In.CoreFilesDirPath, "gonad.stamp.json")
+}
+
+// irMetaStampIsCurrent is true if the last complete run was by this gonad build with these settings, so that all gonad.json files not outdated by their coreimp.json are known to be current
+func (me *session) irMetaStampIsCurrent() bool {
+	var stamp irMetaStamp
+	jsonbytes, err := ioutil.ReadFile(me.irMetaStampFilePath())
+	return err == nil && json.Unmarshal(jsonbytes, &stamp) == nil && stamp.staleness(me) == ""
+}
+
+func (me *session) writeIrMetaStampFile() error {
+	jsonbytes, err := json.Marshal(me.curIrMetaStamp())
+	if err == nil {
+		err = ufs.WriteBinaryFile(me.irMetaStampFilePath(), jsonbytes)
+	}
+	return err
 }
 
 func (me *irMeta) writeAsJsonTo(w io.Writer) error {
+	me.Gonad = me.mod.proj.sess.curIrMetaStamp()
 	jsonenc := json.NewEncoder(w)
 	jsonenc.SetIndent("", "\t")
 	return jsonenc.Encode(me)
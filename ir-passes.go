@@ -0,0 +1,170 @@
+package gonad
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/metaleap/go-util/fs"
+	"github.com/metaleap/go-util/slice"
+)
+
+/*
+The registry of "post" passes over the Golang IR (see
+ir-ast-ops-post.go), run per module in dependency order
+after all modules completed their "prep" stage.
+Gonad.CodeGen.EnablePasses / DisablePasses in bower.json
+select which ones run (disabling one also disables all
+those requiring it), --print-after, --dump-ir and
+--pass-timings help with inspecting and bisecting them.
+A few are part of the "prep" stage instead, registered
+here just to be configurable the same way.
+*/
+
+type irPass struct {
+	name  string
+	deps  []string // passes that must have run before this one
+	optIn bool     // off unless listed in Gonad.CodeGen.EnablePasses
+	prep  bool     // not run as a "post" pass but during the "prep" stage, see irAst.prepMiscFixups
+	run   func(*irAst)
+}
+
+var (
+	irPasses = []*irPass{
+		{name: "flattenIfs", optIn: true, prep: true},
+		{name: "fixupAmpCtors", run: (*irAst).postFixupAmpCtors},
+		{name: "linkUpTcMemberFuncs", run: (*irAst).postLinkUpTcMemberFuncs},
+		{name: "linkUpTcInstDecls", deps: []string{"linkUpTcMemberFuncs"}, run: (*irAst).postLinkUpTcInstDecls},
+		{name: "initialFixups", deps: []string{"fixupAmpCtors"}, run: (*irAst).postInitialFixups},
+		{name: "ensureArgTypes", deps: []string{"linkUpTcInstDecls", "initialFixups"}, run: (*irAst).postEnsureArgTypes},
+		{name: "perFuncFixups", deps: []string{"ensureArgTypes"}, run: (*irAst).postPerFuncFixups},
+		{name: "finalFixups", deps: []string{"perFuncFixups"}, run: (*irAst).postFinalFixups},
+	}
+)
+
+func irPassByName(name string) *irPass {
+	for _, pass := range irPasses {
+		if pass.name == name {
+			return pass
+		}
+	}
+	return nil
+}
+
+func irPassNames() (names []string) {
+	for _, pass := range irPasses {
+		names = append(names, pass.name)
+	}
+	return
+}
+
+// irPassesPipeline returns the enabled passes, ordered such that each runs after its deps (and otherwise in registry order).
+// Passes requiring a disabled one are disabled too, unless explicitly enabled: that's an error.
+func irPassesPipeline(enable []string, disable []string) (pipeline []*irPass, err error) {
+	enabled, explicit := map[string]bool{}, map[string]bool{}
+	for _, pass := range irPasses {
+		enabled[pass.name] = !pass.optIn
+	}
+	for i, names := range [][]string{enable, disable} {
+		for _, name := range names {
+			if irPassByName(name) == nil {
+				return nil, fmt.Errorf("unknown IR pass '%s', known ones are: %s", name, strings.Join(irPassNames(), ", "))
+			}
+			enabled[name], explicit[name] = (i == 0), (i == 0)
+		}
+	}
+	for again := true; again; {
+		again = false
+		for _, pass := range irPasses {
+			for _, dep := range pass.deps {
+				if enabled[pass.name] && !enabled[dep] {
+					if explicit[pass.name] {
+						return nil, fmt.Errorf("IR pass '%s' requires IR pass '%s', which is disabled", pass.name, dep)
+					}
+					enabled[pass.name], again = false, true
+				}
+			}
+		}
+	}
+	done := map[string]bool{}
+	for again := true; again; {
+		again = false
+		for _, pass := range irPasses {
+			if isready := enabled[pass.name] && !done[pass.name]; isready {
+				for _, dep := range pass.deps {
+					isready = isready && done[dep]
+				}
+				if isready {
+					done[pass.name], again, pipeline = true, true, append(pipeline, pass)
+					break // so that registry order wins whenever possible
+				}
+			}
+		}
+	}
+	for name, isenabled := range enabled {
+		if isenabled && !done[name] {
+			return nil, fmt.Errorf("IR pass '%s' takes part in a dependency cycle", name)
+		}
+	}
+	return
+}
+
+// irPassEnabled is true if the named pass is in our pipeline, as needed to check for "prep" passes
+func (me *session) irPassEnabled(name string) bool {
+	for _, pass := range me.irPassPipeline {
+		if pass.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runOn runs the pass on ast, adding its duration to runtime
+func (me *irPass) runOn(ast *irAst, runtime *int64) {
+	starttime := time.Now()
+	me.run(ast)
+	atomic.AddInt64(runtime, int64(time.Since(starttime)))
+	ast.verifyAfter("IR pass " + me.name)
+	ast.dumpIrStage(me.name)
+	if ast.mod.proj.sess.flag.PrintAfter == me.name {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, ";; IR of %s after pass %s:\n", ast.mod.qName, me.name)
+		ast.writeAsPrettyTo(&buf)
+		ast.mod.proj.sess.printf("%s", buf.Bytes())
+	}
+}
+
+// irBadDumpStage returns the first --dump-ir stage that is neither "prep" nor "post" nor a "post" IR pass name
+func (me *session) irBadDumpStage() string {
+	for _, stage := range me.flag.DumpIr {
+		if pass := irPassByName(stage); stage != "prep" && stage != "post" && (pass == nil || pass.prep) {
+			return stage
+		}
+	}
+	return ""
+}
+
+// dumpIrStage writes gonad.ir.<NN>-<stage>.txt next to gonad.json if --dump-ir asks for that stage, numbered in run order for easy diffing
+func (me *irAst) dumpIrStage(stage string) {
+	sess := me.mod.proj.sess
+	if !uslice.StrHas(sess.flag.DumpIr, stage) {
+		return
+	}
+	num := len(sess.irPassPipeline) + 1 // "post"
+	if stage == "prep" {
+		num = 0
+	}
+	for i, pass := range sess.irPassPipeline {
+		if pass.name == stage {
+			num = i + 1
+		}
+	}
+	var buf bytes.Buffer
+	me.writeAsPrettyTo(&buf)
+	if err := ufs.WriteBinaryFile(filepath.Join(filepath.Dir(me.mod.irMetaFilePath), fmt.Sprintf("gonad.ir.%02d-%s.txt", num, stage)), buf.Bytes()); err != nil {
+		panic(err)
+	}
+}
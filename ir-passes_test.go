@@ -0,0 +1,63 @@
+package gonad
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIrPassesPipeline(t *testing.T) {
+	names := func(pipeline []*irPass) string {
+		var s []string
+		for _, pass := range pipeline {
+			s = append(s, pass.name)
+		}
+		return strings.Join(s, ",")
+	}
+	for _, tc := range []struct {
+		enable, disable []string
+		want            string
+	}{
+		{nil, nil, "fixupAmpCtors,linkUpTcMemberFuncs,linkUpTcInstDecls,initialFixups,ensureArgTypes,perFuncFixups,finalFixups"},
+		{[]string{"flattenIfs"}, nil, "flattenIfs,fixupAmpCtors,linkUpTcMemberFuncs,linkUpTcInstDecls,initialFixups,ensureArgTypes,perFuncFixups,finalFixups"},
+		{nil, []string{"perFuncFixups"}, "fixupAmpCtors,linkUpTcMemberFuncs,linkUpTcInstDecls,initialFixups,ensureArgTypes"},
+		{nil, []string{"linkUpTcMemberFuncs"}, "fixupAmpCtors,initialFixups"},
+		{[]string{"finalFixups"}, []string{"perFuncFixups"}, "error: IR pass 'finalFixups' requires IR pass 'perFuncFixups', which is disabled"},
+		{[]string{"noSuchPass"}, nil, "error: unknown IR pass 'noSuchPass'"},
+	} {
+		got := ""
+		if pipeline, err := irPassesPipeline(tc.enable, tc.disable); err != nil {
+			got = "error: " + err.Error()
+		} else {
+			got = names(pipeline)
+		}
+		if !strings.HasPrefix(got, tc.want) {
+			t.Errorf("enable %v, disable %v: want %s, got %s", tc.enable, tc.disable, tc.want, got)
+		}
+	}
+}
+
+// flattenIfs is a "prep" pass: it runs in prepMiscFixups (as it did before the pass registry), not in the "post" pipeline
+func TestIrPassFlattenIfs(t *testing.T) {
+	for _, enable := range []bool{false, true} {
+		sess := newSession(Options{})
+		var err error
+		if sess.irPassPipeline, err = irPassesPipeline(nil, nil); enable {
+			sess.irPassPipeline, err = irPassesPipeline([]string{"flattenIfs"}, nil)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mod := &modPkg{qName: "My.Mod", proj: &sess.proj}
+		mod.irMeta = &irMeta{mod: mod, proj: &sess.proj}
+		irast := &irAst{mod: mod, irM: mod.irMeta}
+		ifx, ifnotx := ªIf(ªSymGo("x")), ªIf(ªO1("!", ªSymGo("x")))
+		ifx.Then.add(ªRet(ªI(1)))
+		ifnotx.Then.add(ªRet(ªI(2)))
+		block := ªBlock(ifx, ifnotx)
+		irast.add(block)
+		irast.prepMiscFixups(nil)
+		if flattened := len(block.Body) == 1 && ifx.Else != nil; flattened != enable {
+			t.Errorf("flattenIfs enabled: %t, but flattened: %t", enable, flattened)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package gonad
 
 import (
 	"fmt"
@@ -117,6 +117,16 @@ func (me *irANamedTypeRef) hasTypeInfo() bool {
 	return me != nil && me.RefAlias != "" || me.RefArray != nil || me.RefFunc != nil || me.RefInterface != nil || me.RefPtr != nil || me.RefStruct != nil || me.RefUnknown != 0
 }
 
+// numTypeRefKinds should never exceed 1: a type is either an alias, or a func, or a struct etc.
+func (me *irANamedTypeRef) numTypeRefKinds() (num int) {
+	for _, isset := range []bool{me.RefAlias != "", me.RefUnknown != 0, me.RefInterface != nil, me.RefFunc != nil, me.RefStruct != nil, me.RefArray != nil, me.RefPtr != nil} {
+		if isset {
+			num++
+		}
+	}
+	return
+}
+
 func (me *irANamedTypeRef) setBothNamesFromPsName(psname string) {
 	me.NamePs = psname
 	me.NameGo = sanitizeSymbolForGo(psname, me.Export)
@@ -212,7 +222,7 @@ func (me *irATypeRefFunc) copyArgTypesOnlyFrom(namesIfMeNil bool, from *irATypeR
 		}
 		return meargs
 	}
-	if from != nil {
+	if me != nil && from != nil {
 		me.Args = copyargs(me.Args, from.Args)
 		me.Rets = copyargs(me.Rets, from.Rets)
 	}
@@ -292,27 +302,15 @@ func (me *irATypeRefStruct) memberByPsName(nameps string) (mem *irANamedTypeRef)
 	return
 }
 
-func (me *irMeta) goTypeDefByGoName(goname string) *irANamedTypeRef {
-	for _, gtd := range me.GoTypeDefs {
-		if gtd.NameGo == goname {
-			return gtd
-		}
-	}
-	return nil
+func (me *irMeta) goTypeDefByGoName(goname string) (gtd *irANamedTypeRef) {
+	me.lookup(func(idx *irMetaIdx) { gtd = idx.goTypeDefsByGo[goname] })
+	return
 }
 
-func (me *irMeta) goTypeDefByPsName(psname string) *irANamedTypeRef {
-	var gtdi *irANamedTypeRef
-	for _, gtd := range me.GoTypeDefs {
-		if gtd.NamePs == psname {
-			if gtd.RefInterface != nil {
-				gtdi = gtd
-			} else {
-				return gtd
-			}
-		}
-	}
-	return gtdi
+// goTypeDefByPsName prefers the non-interface type-def of that name, if any
+func (me *irMeta) goTypeDefByPsName(psname string) (gtd *irANamedTypeRef) {
+	me.lookup(func(idx *irMetaIdx) { gtd = idx.goTypeDefsByPs[psname] })
+	return
 }
 
 func (me *irMeta) populateGoTypeDefs() {
@@ -342,6 +340,7 @@ func (me *irMeta) populateGoTypeDefs() {
 			}
 		}
 		me.GoTypeDefs = append(me.GoTypeDefs, gtd)
+		me.idxInvalidate()
 	}
 	for _, tc := range me.EnvTypeClasses {
 		tsynfound := false
@@ -366,6 +365,7 @@ func (me *irMeta) populateGoTypeDefs() {
 		}
 	}
 	me.GoTypeDefs = append(me.GoTypeDefs, me.toIrADataTypeDefs(me.EnvTypeDataDecls)...)
+	me.idxInvalidate()
 }
 
 func (me *irAst) resolveGoTypeRefFromQName(tref string) (pname string, tname string) {
@@ -381,23 +381,27 @@ func (me *irAst) resolveGoTypeRefFromQName(tref string) (pname string, tname str
 			pname = ""
 			switch tname {
 			case "Char":
-				tname = "rune"
+				if tname = "rune"; me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16 {
+					pname, tname = "𝒈", "Char"
+				}
 			case "String":
-				tname = "string"
+				if tname = "string"; me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.StringRepr == strReprUtf16 {
+					pname, tname = "𝒈", "Str"
+				}
 			case "Boolean":
 				tname = "bool"
 			case "Number":
 				tname = "float64"
 			case "Int":
-				tname = "int"
+				tname = "int32" // JS ints are 32-bit, see also irAOp2.goIntrinsic
 			default:
 				tname = "interface{/*Prim." + tname + "*/}"
 			}
 		} else {
 			qn, foundimport, isffi := pname, false, strings.HasPrefix(pname, prefixDefaultFfiPkgNs)
 			if !isffi {
-				if mod = findModuleByQName(qn); mod == nil {
-					if mod = findModuleByPName(qn); mod == nil {
+				if mod = me.mod.findModuleByQName(qn); mod == nil {
+					if mod = me.mod.findModuleByPName(qn); mod == nil {
 						panic(notImplErr("module qname", qn, me.mod.srcFilePath))
 					}
 				}
@@ -454,7 +458,7 @@ func (me *irMeta) toIrADataTypeDefs(typedatadecls []*irMTypeDataDecl) (gtds irAN
 			} else {
 				for _, ctor := range td.Ctors {
 					ctor.gtd = &irANamedTypeRef{Export: me.hasExport(gid.NamePs + "ĸ" + ctor.Name),
-						RefStruct: &irATypeRefStruct{PassByPtr: (hasctorargs && len(ctor.Args) >= Proj.BowerJsonFile.Gonad.CodeGen.PtrStructMinFieldCount)}}
+						RefStruct: &irATypeRefStruct{PassByPtr: (hasctorargs && len(ctor.Args) >= me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.PtrStructMinFieldCount)}}
 					ctor.gtd.setBothNamesFromPsName(gid.NamePs + "۰" + ctor.Name)
 					ctor.gtd.NamePs = ctor.Name
 					for ia, ctorarg := range ctor.Args {
@@ -526,7 +530,7 @@ func (me *irMeta) toIrATypeRef(tdict map[string][]string, tr *irMTypeRef) interf
 		if nextrow, _ := me.toIrATypeRef(tdict, tr.RCons.Right).(*irATypeRefStruct); nextrow != nil {
 			rectype.Fields = append(rectype.Fields, nextrow.Fields...)
 		}
-		rectype.PassByPtr = len(rectype.Fields) >= Proj.BowerJsonFile.Gonad.CodeGen.PtrStructMinFieldCount
+		rectype.PassByPtr = len(rectype.Fields) >= me.mod.proj.sess.proj.BowerJsonFile.Gonad.CodeGen.PtrStructMinFieldCount
 		return rectype
 	} else if tr.TypeApp != nil {
 		if tr.TypeApp.Left.TypeConstructor == "Prim.Record" {
@@ -0,0 +1,182 @@
+package gonad
+
+import (
+	"fmt"
+)
+
+/*
+Golang intermediate-representation AST:
+invariant checks run after every prep step and post pass
+in --verify-ir mode, so that a pass corrupting the IR gets
+named right away rather than blowing up much later.
+*/
+
+type irVerifier struct {
+	seen     map[irA]string // node => its path, to detect nodes attached in more than one place
+	symrefs  map[*irASym]string
+	firstErr error
+}
+
+// verifyAfter panics (naming the given prep step or post pass) if the IR breaks any invariant, but only in --verify-ir mode.
+func (me *irAst) verifyAfter(stage string) {
+	if me.mod.proj.sess.flag.VerifyIr {
+		if err := me.verify(); err != nil {
+			panic(fmt.Errorf("%s: IR invariant broken by %s: %v", me.mod.srcFilePath, stage, err))
+		}
+	}
+}
+
+func (me *irAst) verify() error {
+	v := &irVerifier{seen: map[irA]string{}, symrefs: map[*irASym]string{}}
+	v.block("Body", &me.irABlock, nil)
+	for _, gtd := range me.irM.GoTypeDefs {
+		if gtd.RefStruct != nil {
+			for _, method := range gtd.RefStruct.Methods {
+				if method.RefFunc.impl != nil {
+					v.block(gtd.NameGo+"."+method.NameGo, method.RefFunc.impl, method.RefFunc.impl.parent)
+				}
+			}
+		}
+	}
+	for i, tcf := range me.culled.typeCtorFuncs {
+		v.node(fmt.Sprintf("culled.typeCtorFuncs[%d]", i), tcf, tcf.parent)
+	}
+	//	once all live nodes are known: resolved syms must not refer to nodes that have since been dropped or replaced
+	for sym, path := range v.symrefs {
+		if _, islive := v.seen[sym.refto]; !islive {
+			v.fail(path, "irASym '%s' refers to a %T no longer in the IR", sym.NameGo, sym.refto)
+		}
+	}
+	return v.firstErr
+}
+
+func (me *irVerifier) fail(path string, msg string, args ...interface{}) {
+	if me.firstErr == nil {
+		me.firstErr = fmt.Errorf("%s: %s", path, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (me *irVerifier) block(path string, a *irABlock, parent irA) {
+	if a != nil {
+		me.node(path, a, parent)
+	}
+}
+
+func (me *irVerifier) node(path string, a irA, parent irA) {
+	if irAIsNil(a) || me.firstErr != nil {
+		return
+	}
+	ab := a.Base()
+	if parent != nil && a.Parent() != parent {
+		me.fail(path, "%T has a wrong parent (%T instead of %T)", a, a.Parent(), parent)
+	}
+	if prevpath, dupe := me.seen[a]; dupe {
+		me.fail(path, "%T is also attached at %s", a, prevpath)
+	}
+	me.seen[a] = path
+	if numrefs := ab.numTypeRefKinds(); numrefs > 1 {
+		me.fail(path, "%T has %d kinds of type info at once", a, numrefs)
+	} else if ab.RefFunc != nil {
+		for _, fargs := range []irANamedTypeRefs{ab.RefFunc.Args, ab.RefFunc.Rets} {
+			for i, farg := range fargs {
+				if farg == nil {
+					me.fail(path, "%T has a nil func arg/ret type at index %d", a, i)
+				}
+			}
+		}
+	}
+	child := func(name string, c irA) { me.node(path+"."+name, c, a) }
+	switch x := a.(type) {
+	case *irABlock:
+		names := map[string]bool{}
+		for i, stmt := range x.Body {
+			if stmt == nil {
+				me.fail(path, "nil statement at index %d", i)
+				continue
+			}
+			stmtpath := fmt.Sprintf("Body[%d]", i)
+			switch stmt.(type) {
+			case *irALet, *irAConst, *irAFunc:
+				if name := stmt.Base().NameGo; name != "" {
+					if names[name] {
+						me.fail(path, "'%s' declared more than once", name)
+					}
+					names[name], stmtpath = true, stmtpath+":"+name
+				}
+			}
+			child(stmtpath, stmt)
+		}
+	case *irACall:
+		child("Callee", x.Callee)
+		for i, arg := range x.CallArgs {
+			child(fmt.Sprintf("CallArgs[%d]", i), arg)
+		}
+	case *irAConst:
+		child("ConstVal", x.ConstVal)
+	case *irADot:
+		child("DotLeft", x.DotLeft)
+		child("DotRight", x.DotRight)
+	case *irAFor:
+		child("ForCond", x.ForCond)
+		if x.ForRange != nil {
+			child("ForRange", x.ForRange)
+		}
+		me.block(path+".ForDo", x.ForDo, a)
+		for i, fi := range x.ForInit {
+			child(fmt.Sprintf("ForInit[%d]", i), fi)
+		}
+		for i, fs := range x.ForStep {
+			child(fmt.Sprintf("ForStep[%d]", i), fs)
+		}
+	case *irACtor:
+		me.block(path+".FuncImpl", x.FuncImpl, a)
+	case *irAFunc:
+		me.block(path+".FuncImpl", x.FuncImpl, a)
+	case *irAIf:
+		child("If", x.If)
+		me.block(path+".Then", x.Then, a)
+		me.block(path+".Else", x.Else, a)
+	case *irAIndex:
+		child("IdxLeft", x.IdxLeft)
+		child("IdxRight", x.IdxRight)
+	case *irAOp1:
+		child("Of", x.Of)
+	case *irAOp2:
+		child("Left", x.Left)
+		child("Right", x.Right)
+	case *irAPanic:
+		child("PanicArg", x.PanicArg)
+	case *irAPatMatchFail:
+		for i, sv := range x.Scrutinees {
+			child(fmt.Sprintf("Scrutinees[%d]", i), sv)
+		}
+	case *irARet:
+		child("RetArg", x.RetArg)
+	case *irASet:
+		child("SetLeft", x.SetLeft)
+		child("ToRight", x.ToRight)
+	case *irALet:
+		child("LetVal", x.LetVal)
+	case *irAIsType:
+		child("ExprToTest", x.ExprToTest)
+	case *irAToType:
+		child("ExprToConv", x.ExprToConv)
+	case *irALitArr:
+		for i, av := range x.ArrVals {
+			child(fmt.Sprintf("ArrVals[%d]", i), av)
+		}
+	case *irALitObj:
+		for i, of := range x.ObjFields {
+			child(fmt.Sprintf("ObjFields[%d]", i), of)
+		}
+	case *irALitObjField:
+		child("FieldVal", x.FieldVal)
+	case *irASym:
+		if x.refto != nil {
+			me.symrefs[x] = path
+		}
+	case *irAComments, *irAPkgSym, *irANil, *irALitBool, *irALitNum, *irALitInt, *irALitStr:
+	default:
+		me.fail(path, "unknown node type %T", a)
+	}
+}
@@ -1,11 +1,14 @@
-package main
+package gonad
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"sync"
+	"sync/atomic"
 
 	"github.com/metaleap/go-util/dev/ps"
 	"github.com/metaleap/go-util/fs"
@@ -20,28 +23,70 @@ Holds intermediate representations in irMeta and irAst.
 
 type modPkg struct {
 	reGenIr        bool
-	qName          string //	eg	Control.Monad.Eff.Uncurried, My.Main etc
-	lName          string //	eg	Uncurried, Main etc
-	pName          string //	eg	Control_Monad_Eff_Uncurried, My_Main etc
-	srcFilePath    string //	eg	bower_components/purescript-eff/src/Control/Monad/Eff/Uncurried.purs or src/My/Main.purs etc
-	impFilePath    string //	eg	output/Control.Monad.Eff.Uncurried/coreimp.json, output/My.Main/coreimp.json etc
-	extFilePath    string //	eg	output/Control.Monad.Eff.Uncurried/externs.json, output/My.Main/externs.json etc
-	irMetaFilePath string //	eg	output/Control.Monad.Eff.Uncurried/gonad.json, output/My.Main/gonad.json etc
-	goOutDirPath   string //	eg	Control/Monad/Eff/Uncurried, My/Main etc
-	goOutFilePath  string //	eg	Control/Monad/Eff/Uncurried/Uncurried.go, My/Main/Main.go etc
+	qName          string   //	eg	Control.Monad.Eff.Uncurried, My.Main etc
+	lName          string   //	eg	Uncurried, Main etc
+	pName          string   //	eg	Control_Monad_Eff_Uncurried, My_Main etc
+	srcFilePath    string   //	eg	bower_components/purescript-eff/src/Control/Monad/Eff/Uncurried.purs or src/My/Main.purs etc
+	impFilePath    string   //	eg	output/Control.Monad.Eff.Uncurried/coreimp.json, output/My.Main/coreimp.json etc
+	extFilePath    string   //	eg	output/Control.Monad.Eff.Uncurried/externs.json, output/My.Main/externs.json etc
+	irMetaFilePath string   //	eg	output/Control.Monad.Eff.Uncurried/gonad.json, output/My.Main/gonad.json etc
+	goOutDirPath   string   //	eg	Control/Monad/Eff/Uncurried, My/Main etc
+	goOutFilePath  string   //	eg	Control/Monad/Eff/Uncurried/Uncurried.go, My/Main/Main.go etc
+	ffiFilePaths   []string //	eg	src/My/Main.go, src/My/Main_windows.go etc (user-supplied FFI, see ffi.go)
 
 	irMeta        *irMeta
 	irAst         *irAst
+	irAstLoaded   bool            // from gonad.ast.json, see loadIrAst
+	irMetaEager   bool            // irMeta was set up by ensureModPkgIrMetas, rather than lazily by ensureIrMeta
+	irMetaReady   int32           // atomic: see ensureIrMeta
+	irMetaMutex   sync.Mutex      // see ensureIrMeta
+	irMetaStale   string          // why the existing gonad.json was outdated (by a gonad upgrade, Gonad.CodeGen changes or an outdated import), see loadPkgIrMeta
 	proj          *psBowerProject // parent
 	gopkgfilepath string          // full target file path (not necessarily absolute but starting with the given gopath)
 	ext           *udevps.Extern
 	coreimp       *psCoreImp
+	diags         []*Diagnostic // see forAll: any but warnings mean the module failed, so no further ops (and no outputs)
 }
 
-func findModuleByQName(qname string) (modinfo *modPkg) {
+// findModuleByQName also loads the module's irMeta if not yet done, see ensureIrMeta
+func (me *session) findModuleByQName(qname string) (modinfo *modPkg) {
+	if modinfo = me.modPkgByQName(qname); modinfo != nil {
+		modinfo.ensureIrMeta()
+	}
+	return
+}
+
+// findModuleByPName also loads the module's irMeta if not yet done, see ensureIrMeta
+func (me *session) findModuleByPName(pname string) (modinfo *modPkg) {
+	if modinfo = me.modPkgByPName(pname); modinfo != nil {
+		modinfo.ensureIrMeta()
+	}
+	return
+}
+
+// findModuleByQName is session.findModuleByQName, except that looking up ourselves never goes through ensureIrMeta: we might well be in the midst of populating our irMeta right then
+func (me *modPkg) findModuleByQName(qname string) (modinfo *modPkg) {
+	if modinfo = me.proj.sess.modPkgByQName(qname); modinfo != nil && modinfo != me {
+		modinfo.ensureIrMeta()
+	}
+	return
+}
+
+// findModuleByPName is session.findModuleByPName, except that looking up ourselves never goes through ensureIrMeta, see findModuleByQName
+func (me *modPkg) findModuleByPName(pname string) (modinfo *modPkg) {
+	if modinfo = me.proj.sess.modPkgByPName(pname); modinfo != nil && modinfo != me {
+		modinfo.ensureIrMeta()
+	}
+	return
+}
+
+func (me *session) modPkgByQName(qname string) (modinfo *modPkg) {
+	if me.modPkgIdx.byQName != nil {
+		return me.modPkgIdx.byQName[qname]
+	}
 	if qname != "" {
-		if modinfo = Proj.moduleByQName(qname); modinfo == nil {
-			for _, dep := range Deps {
+		if modinfo = me.proj.moduleByQName(qname); modinfo == nil {
+			for _, dep := range me.deps {
 				if modinfo = dep.moduleByQName(qname); modinfo != nil {
 					return
 				}
@@ -51,10 +96,16 @@ func findModuleByQName(qname string) (modinfo *modPkg) {
 	return
 }
 
-func findModuleByPName(pname string) (modinfo *modPkg) {
+func (me *session) modPkgByPName(pname string) (modinfo *modPkg) {
+	if me.modPkgIdx.byPName != nil && pname != "" {
+		if modinfo = me.modPkgIdx.byPName[strReplUnderscore2ꓸ.Replace(pname)]; modinfo == nil {
+			modinfo = me.modPkgIdx.byPName[pname]
+		}
+		return
+	}
 	if pname != "" {
-		if modinfo = Proj.moduleByPName(pname); modinfo == nil {
-			for _, dep := range Deps {
+		if modinfo = me.proj.moduleByPName(pname); modinfo == nil {
+			for _, dep := range me.deps {
 				if modinfo = dep.moduleByPName(pname); modinfo != nil {
 					return
 				}
@@ -64,20 +115,60 @@ func findModuleByPName(pname string) (modinfo *modPkg) {
 	return
 }
 
+func (me *modPkg) failed() bool {
+	for _, diag := range me.diags {
+		if !diag.Warning {
+			return true
+		}
+	}
+	return false
+}
+
 func (me *modPkg) impPath() string {
 	return path.Join(me.proj.GoOut.PkgDirPath, me.goOutDirPath)
 }
 
+// loadPkgIrMeta sets irMetaStale instead of loading if the gonad.json was written by another gonad build, format version or Gonad.CodeGen settings
 func (me *modPkg) loadPkgIrMeta() (err error) {
 	var jsonbytes []byte
 	if jsonbytes, err = ioutil.ReadFile(me.irMetaFilePath); err == nil {
-		if err = json.Unmarshal(jsonbytes, &me.irMeta); err == nil {
-			me.irMeta.mod = me
+		var stamped struct{ Gonad irMetaStamp } // checked first, as older formats might not even unmarshal
+		if err = json.Unmarshal(jsonbytes, &stamped); err == nil {
+			if me.irMetaStale = stamped.Gonad.staleness(me.proj.sess); me.irMetaStale == "" {
+				if err = json.Unmarshal(jsonbytes, &me.irMeta); err == nil {
+					me.irMeta.mod = me
+				}
+			}
 		}
 	}
 	return
 }
 
+// ensureIrMeta loads (and populates) our gonad.json the first time some other module needs it (see session.irMetasLoadLazily), but only in lazy mode: otherwise all irMetas are set up by then anyway
+func (me *modPkg) ensureIrMeta() {
+	if me.proj.sess.irMetasLoadLazily && atomic.LoadInt32(&me.irMetaReady) == 0 {
+		me.irMetaMutex.Lock()
+		defer me.irMetaMutex.Unlock()
+		if atomic.LoadInt32(&me.irMetaReady) == 0 {
+			if err := me.loadPkgIrMeta(); err == nil && me.irMetaStale == "" {
+				me.irMeta.populateFromLoaded()
+			} else {
+				// our gonad.json is outdated or broken: for the importer at hand, it gets re-generated from coreimp.json right now (but not written), while the Compile run is repeated non-lazily (see session.irMetasLazyStale) to also re-generate our .go file and those of all our importers
+				if err != nil && me.irMetaStale == "" {
+					me.irMetaStale = err.Error()
+				}
+				if err = me.reGenPkgIrMeta(); err != nil {
+					panic(err)
+				}
+				me.irMeta.populateFromCoreImp()
+				me.coreimp, me.ext = nil, nil
+				atomic.StoreInt32(&me.proj.sess.irMetasLazyStale, 1)
+			}
+			atomic.StoreInt32(&me.irMetaReady, 1) // only now, once fully populated: our own lookups of ourselves go around ensureIrMeta anyway, see modPkg.findModuleByQName
+		}
+	}
+}
+
 func (me *modPkg) populatePkgIrMeta() {
 	if me.coreimp == nil {
 		me.irMeta.populateFromLoaded()
@@ -87,15 +178,13 @@ func (me *modPkg) populatePkgIrMeta() {
 }
 
 func (me *modPkg) reGenPkgIrMeta() (err error) {
-	var jsonbytes []byte
-	if jsonbytes, err = ioutil.ReadFile(me.extFilePath); err == nil {
-		if err = json.Unmarshal(jsonbytes, &me.ext); err == nil {
-			if jsonbytes, err = ioutil.ReadFile(me.impFilePath); err == nil {
-				if err = json.Unmarshal(jsonbytes, &me.coreimp); err == nil {
-					me.coreimp.mod, me.coreimp.My.ImpFilePath = me, me.impFilePath
-					me.irMeta = &irMeta{isDirty: true, mod: me, proj: me.proj}
-				}
-			}
+	me.ext, me.coreimp = &udevps.Extern{}, &psCoreImp{}
+	// of the externs.json, we only ever use the exports: its (often much larger) other sections get skipped while streaming
+	if err = jsonDecodeFileStreamed(me.extFilePath, me.ext, "EfVersion", "EfModuleName", "EfExports"); err == nil {
+		if err = jsonDecodeFileStreamed(me.impFilePath, me.coreimp); err == nil {
+			me.coreimp.mod, me.coreimp.My.ImpFilePath = me, me.impFilePath
+			me.coreimp.PrepTopLevel() // already now, as populating the irMeta needs the NamedRequires to find the foreign imports
+			me.irMeta = &irMeta{isDirty: true, mod: me, proj: me.proj}
 		}
 	}
 	return
@@ -103,9 +192,11 @@ func (me *modPkg) reGenPkgIrMeta() (err error) {
 
 func (me *modPkg) prepIrAst() {
 	me.coreimp.InitAstOnLoaded()
-	me.coreimp.PrepTopLevel()
 	me.irAst = &irAst{mod: me, irM: me.irMeta}
 	me.irAst.prepFromCoreImp()
+	me.irAst.dumpIrStage("prep")
+	// from here on, all we need is in irMeta and irAst: let the GC have the (much bulkier) raw inputs
+	me.coreimp, me.ext = nil, nil
 }
 
 func (me *modPkg) reGenPkgIrAst() {
@@ -114,19 +205,46 @@ func (me *modPkg) reGenPkgIrAst() {
 
 func (me *modPkg) writeGoFile() (err error) {
 	var buf bytes.Buffer
-	if !Flag.NoPrefix {
+	if !me.proj.sess.flag.NoPrefix {
 		fmt.Fprintf(&buf, "// Generated by gonad from: %s, generated from: %s\n", me.impFilePath, me.srcFilePath)
 	}
 	if err = me.irAst.writeAsGoTo(&buf); err == nil {
 		err = ufs.WriteBinaryFile(me.gopkgfilepath, buf.Bytes())
 	}
+	if err == nil && (len(me.ffiFilePaths) > 0 || len(me.ffiGoFileCopies()) > 0) {
+		if err = me.checkFfiGoFiles(); err == nil {
+			err = me.writeFfiGoFiles()
+		}
+	}
+	return
+}
+
+// isFromAst is true in --from-ast mode for modules having both a (non-stale) gonad.json and a gonad.ast.json to re-generate from
+func (me *modPkg) isFromAst() bool {
+	return me.proj.sess.flag.FromAst && me.irMetaStale == "" && ufs.FileExists(me.irMetaFilePath) && ufs.FileExists(me.irAstFilePath())
+}
+
+func (me *modPkg) irAstFilePath() string {
+	return me.irMetaFilePath[:len(me.irMetaFilePath)-len(".json")] + ".ast.json"
+}
+
+// loadIrAst is the --from-ast alternative to prepIrAst and reGenPkgIrAst: the IR is read from a gonad.ast.json as written by writeIrAstFile
+func (me *modPkg) loadIrAst() (err error) {
+	var f *os.File
+	if f, err = os.Open(me.irAstFilePath()); err == nil {
+		defer f.Close()
+		me.irAst, me.irAstLoaded = &irAst{mod: me, irM: me.irMeta}, true
+		if err = me.irAst.loadFromJson(f); err != nil {
+			err = fmt.Errorf("%s: %v", me.irAstFilePath(), err)
+		}
+	}
 	return
 }
 
 func (me *modPkg) writeIrAstFile() (err error) {
 	var buf bytes.Buffer
 	if err = me.irAst.writeAsJsonTo(&buf); err == nil {
-		err = ufs.WriteBinaryFile(me.irMetaFilePath[:len(me.irMetaFilePath)-len(".json")]+".ast.json", buf.Bytes())
+		err = ufs.WriteBinaryFile(me.irAstFilePath(), buf.Bytes())
 	}
 	return
 }
@@ -1,10 +1,11 @@
-package main
+package gonad
 
 import (
 	"errors"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/metaleap/go-util/dev/bower"
 	"github.com/metaleap/go-util/dev/go"
@@ -19,22 +20,26 @@ or one of its dependency libs usually found in `bower_components`.
 type psBowerFile struct {
 	udevbower.BowerFile
 
-	Gonad struct { // all settings in here apply to all Deps equally as they do to the main Proj --- ie. the former get a copy of the latter, ignoring their own Gonad field even if present
+	Gonad struct { // all settings in here apply to all deps equally as they do to the main proj --- ie. the former get a copy of the latter, ignoring their own Gonad field even if present
 		In struct {
 			CoreFilesDirPath string // dir path containing Some.Module.QName/coreimp.json files
 		}
 		Out struct {
-			DumpAst         bool   // dumps an additional gonad.ast.json next to gonad.json
+			DumpAst         bool   // dumps an additional gonad.ast.json next to gonad.json (which --from-ast can re-generate from)
 			MainDepLevel    int    // temporary option
 			GoDirSrcPath    string // defaults to the first `GOPATH` found that has a `src` sub-directory
 			GoNamespaceProj string
 			GoNamespaceDeps string
+			Facades         []string // exports to wrap in idiomatic Go facade packages, eg. "My.Mod.frob" or "My.Mod.frob @Int as FrobInt", see facades.go
 		}
 		CodeGen struct {
 			// TypeClasses2Interfaces bool
 			// SaturateFuncArities    bool
-			FlattenIfs             bool
+			FlattenIfs             bool     // same as listing "flattenIfs" in EnablePasses
+			EnablePasses           []string // names of opt-in IR passes to run, see ir-passes.go
+			DisablePasses          []string // names of IR passes not to run (along with all those requiring them)
 			PtrStructMinFieldCount int
+			StringRepr             string // "utf8" (default: native Go strings, runes for Chars) or "utf16" (faithful UTF-16 code units), see gonadz/str.go
 		}
 
 		loadedFromJson bool
@@ -50,10 +55,12 @@ type psBowerProject struct {
 	GoOut             struct {
 		PkgDirPath string
 	}
+
+	sess *session
 }
 
 func (me *psBowerProject) ensureOutDirs() (err error) {
-	dirpath := filepath.Join(Proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, me.GoOut.PkgDirPath)
+	dirpath := filepath.Join(me.sess.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, me.GoOut.PkgDirPath)
 	if err = ufs.EnsureDirExists(dirpath); err == nil {
 		for _, depmod := range me.Modules {
 			if err = ufs.EnsureDirExists(filepath.Join(dirpath, depmod.goOutDirPath)); err != nil {
@@ -90,9 +97,9 @@ func (me *psBowerProject) moduleByPName(pname string) *modPkg {
 func (me *psBowerProject) loadFromJsonFile() (err error) {
 	if err = udevbower.LoadFromFile(me.BowerJsonFilePath, &me.BowerJsonFile); err == nil {
 		// populate defaults for Gonad sub-fields
-		cfg, isdep := &me.BowerJsonFile.Gonad, me != &Proj
+		cfg, isdep := &me.BowerJsonFile.Gonad, me != &me.sess.proj
 		if isdep {
-			cfg = &Proj.BowerJsonFile.Gonad
+			cfg = &me.sess.proj.BowerJsonFile.Gonad
 		} else {
 			if cfg.In.CoreFilesDirPath == "" {
 				cfg.In.CoreFilesDirPath = "output"
@@ -110,7 +117,24 @@ func (me *psBowerProject) loadFromJsonFile() (err error) {
 			if cfg.CodeGen.PtrStructMinFieldCount == 0 {
 				cfg.CodeGen.PtrStructMinFieldCount = 2
 			}
-			err = ufs.EnsureDirExists(cfg.Out.GoDirSrcPath)
+			if cfg.CodeGen.StringRepr == "" {
+				cfg.CodeGen.StringRepr = strReprUtf8
+			} else if cfg.CodeGen.StringRepr != strReprUtf8 && cfg.CodeGen.StringRepr != strReprUtf16 {
+				panic("bad bower.json setting: `Gonad{CodeGen{StringRepr}}` must be either \"" + strReprUtf8 + "\" or \"" + strReprUtf16 + "\"")
+			}
+			if cfg.CodeGen.FlattenIfs {
+				cfg.CodeGen.EnablePasses = append(cfg.CodeGen.EnablePasses, "flattenIfs")
+			}
+			if me.sess.irPassPipeline, err = irPassesPipeline(cfg.CodeGen.EnablePasses, cfg.CodeGen.DisablePasses); err == nil {
+				me.sess.irPassRunTimes = make([]int64, len(me.sess.irPassPipeline))
+				if pass := irPassByName(me.sess.flag.PrintAfter); me.sess.flag.PrintAfter != "" && (pass == nil || pass.prep) {
+					err = errors.New("unknown (or \"prep\"-stage) IR pass for --print-after: " + me.sess.flag.PrintAfter)
+				} else if stage := me.sess.irBadDumpStage(); stage != "" {
+					err = errors.New("unknown IR stage for --dump-ir: " + stage)
+				} else if me.sess.facades, err = parseFacadeDecls(cfg.Out.Facades); err == nil {
+					err = ufs.EnsureDirExists(cfg.Out.GoDirSrcPath)
+				}
+			}
 			cfg.loadedFromJson = true
 		}
 		if err == nil {
@@ -148,7 +172,7 @@ func (me *psBowerProject) loadFromJsonFile() (err error) {
 }
 
 func (me *psBowerProject) addModPkgFromPsSrcFileIfCoreimp(relpath string, gopkgdir string) {
-	i, l, opt := strings.LastIndexAny(relpath, "/\\"), len(relpath)-5, Proj.BowerJsonFile.Gonad
+	i, l, opt := strings.LastIndexAny(relpath, "/\\"), len(relpath)-5, me.sess.proj.BowerJsonFile.Gonad
 	modinfo := &modPkg{
 		proj: me, srcFilePath: filepath.Join(me.SrcDirPath, relpath),
 		qName: strReplFsSlash2Dot.Replace(relpath[:l]), lName: relpath[i+1 : l],
@@ -160,12 +184,18 @@ func (me *psBowerProject) addModPkgFromPsSrcFileIfCoreimp(relpath string, gopkgd
 		modinfo.goOutDirPath = relpath[:l]
 		modinfo.goOutFilePath = filepath.Join(modinfo.goOutDirPath, modinfo.qName) + ".go"
 		modinfo.gopkgfilepath = filepath.Join(gopkgdir, modinfo.goOutFilePath)
+		modinfo.ffiFilePaths = findFfiGoFiles(modinfo.srcFilePath)
 		if ufs.FileExists(modinfo.irMetaFilePath) && ufs.FileExists(modinfo.gopkgfilepath) {
 			stalemetaˇimp, _ := ufs.IsNewerThan(modinfo.impFilePath, modinfo.irMetaFilePath)
 			stalepkgˇimp, _ := ufs.IsNewerThan(modinfo.impFilePath, modinfo.gopkgfilepath)
 			stalemetaˇext, _ := ufs.IsNewerThan(modinfo.extFilePath, modinfo.irMetaFilePath)
 			stalepkgˇext, _ := ufs.IsNewerThan(modinfo.extFilePath, modinfo.gopkgfilepath)
 			modinfo.reGenIr = stalemetaˇimp || stalepkgˇimp || stalemetaˇext || stalepkgˇext
+			if me.sess.flag.FromAst {
+				staleast, _ := ufs.IsNewerThan(modinfo.irAstFilePath(), modinfo.gopkgfilepath)
+				modinfo.reGenIr = modinfo.reGenIr || staleast
+			}
+			modinfo.reGenIr = modinfo.reGenIr || modinfo.hasStaleFfiGoFiles()
 		} else {
 			modinfo.reGenIr = true
 		}
@@ -173,24 +203,41 @@ func (me *psBowerProject) addModPkgFromPsSrcFileIfCoreimp(relpath string, gopkgd
 	}
 }
 
-func (me *psBowerProject) forAll(op func(*sync.WaitGroup, *modPkg)) {
+// forAll runs op on all modules in parallel, but skips those already failed: a panic in op fails just its module, recorded in its diags
+func (me *psBowerProject) forAll(op func(*modPkg)) {
 	var wg sync.WaitGroup
 	for _, modinfo := range me.Modules {
-		wg.Add(1)
-		go op(&wg, modinfo)
+		if !modinfo.failed() {
+			wg.Add(1)
+			go func(modinfo *modPkg) {
+				defer wg.Done()
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						modinfo.diags = append(modinfo.diags, newDiagnostic(modinfo.qName, recovered))
+					}
+				}()
+				op(modinfo)
+			}(modinfo)
+		}
 	}
 	wg.Wait()
 }
 
 func (me *psBowerProject) ensureModPkgIrMetas() {
-	me.forAll(func(wg *sync.WaitGroup, modinfo *modPkg) {
-		defer wg.Done()
+	me.forAll(func(modinfo *modPkg) {
 		var err error
-		if modinfo.reGenIr || Flag.ForceAll {
+		if me.sess.irMetasLoadLazily && !(modinfo.reGenIr || me.sess.flag.ForceAll) {
+			return // left to ensureIrMeta, if needed at all
+		}
+		modinfo.irMetaEager = true
+		atomic.StoreInt32(&modinfo.irMetaReady, 1)
+		if (modinfo.reGenIr || me.sess.flag.ForceAll) && !modinfo.isFromAst() {
 			err = modinfo.reGenPkgIrMeta()
-		} else if err = modinfo.loadPkgIrMeta(); err != nil {
+		} else if err = modinfo.loadPkgIrMeta(); err != nil || modinfo.irMetaStale != "" {
 			modinfo.reGenIr = true // we capture this so the .go file later also gets re-gen'd from the re-gen'd IRs
-			println(modinfo.qName + ": regenerating due to error when loading " + modinfo.irMetaFilePath + ": " + err.Error())
+			if err != nil {
+				modinfo.diags = append(modinfo.diags, &Diagnostic{Module: modinfo.qName, Warning: true, Message: "regenerating due to error when loading " + modinfo.irMetaFilePath + ": " + err.Error()})
+			}
 			err = modinfo.reGenPkgIrMeta()
 		}
 		if err != nil {
@@ -199,40 +246,66 @@ func (me *psBowerProject) ensureModPkgIrMetas() {
 	})
 }
 
+// reGenDependentsOfStaleIrMetas also re-generates all (transitive) importers of modules whose gonad.json was stale, as their own gonad.json and .go files embed assumptions about those
+func (me *session) reGenDependentsOfStaleIrMetas() {
+	for again := true; again; {
+		again = false
+		for _, dep := range me.deps {
+			for _, modinfo := range dep.Modules {
+				if modinfo.irMetaEager && modinfo.coreimp == nil && modinfo.irMetaStale == "" { // still on its loaded gonad.json
+					for _, imp := range modinfo.irMeta.Imports {
+						if impmod := me.modPkgByQName(imp.PsModQName); impmod != nil && impmod.irMetaStale != "" {
+							modinfo.irMetaStale, modinfo.reGenIr, again = "imports "+impmod.qName+", which was stale", true, true
+							if err := modinfo.reGenPkgIrMeta(); err != nil {
+								panic(err)
+							}
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
 func (me *psBowerProject) populateModPkgIrMetas() {
-	me.forAll(func(wg *sync.WaitGroup, modinfo *modPkg) {
-		defer wg.Done()
-		modinfo.populatePkgIrMeta()
+	me.forAll(func(modinfo *modPkg) {
+		if modinfo.irMetaEager { // the others get populated as lazily loaded
+			modinfo.populatePkgIrMeta()
+		}
 	})
 }
 
 func (me *psBowerProject) prepModPkirAsts() {
-	me.forAll(func(wg *sync.WaitGroup, modinfo *modPkg) {
-		defer wg.Done()
-		if modinfo.reGenIr || Flag.ForceAll {
-			modinfo.prepIrAst()
+	me.forAll(func(modinfo *modPkg) {
+		if modinfo.reGenIr || me.sess.flag.ForceAll {
+			if modinfo.isFromAst() {
+				if err := modinfo.loadIrAst(); err != nil {
+					panic(err)
+				}
+			} else {
+				modinfo.prepIrAst()
+			}
 		}
 	})
 }
 
 func (me *psBowerProject) reGenModPkirAsts() {
-	me.forAll(func(wg *sync.WaitGroup, modinfo *modPkg) {
-		defer wg.Done()
-		if modinfo.reGenIr || Flag.ForceAll {
+	me.forAll(func(modinfo *modPkg) {
+		if (modinfo.reGenIr || me.sess.flag.ForceAll) && !modinfo.irAstLoaded {
 			modinfo.reGenPkgIrAst()
 		}
 	})
 }
 
 func (me *psBowerProject) writeOutFiles() {
-	me.forAll(func(wg *sync.WaitGroup, m *modPkg) {
-		defer wg.Done()
-		if m.irMeta.isDirty || m.reGenIr || Flag.ForceAll {
+	me.forAll(func(m *modPkg) {
+		if m.irMetaEager && (m.irMeta.isDirty || m.reGenIr || me.sess.flag.ForceAll) { // lazily loaded irMetas stay as they are (if they had to be re-generated, the non-lazy re-run writes them, see ensureIrMeta)
 			//	maybe gonad.json
 			err := m.writeIrMetaFile()
-			if err == nil && (m.reGenIr || Flag.ForceAll) {
+			if err == nil && (m.reGenIr || me.sess.flag.ForceAll) {
 				//	maybe gonad.ast.json
-				if Proj.BowerJsonFile.Gonad.Out.DumpAst {
+				if me.sess.proj.BowerJsonFile.Gonad.Out.DumpAst {
 					err = m.writeIrAstFile()
 				}
 				//	maybe .go file
@@ -240,6 +313,7 @@ func (me *psBowerProject) writeOutFiles() {
 					err = m.writeGoFile()
 				}
 			}
+			m.irAst = nil // all written: only irMetas get consulted from here on, so let the GC have it
 			if err != nil {
 				panic(err)
 			}
@@ -1,4 +1,4 @@
-package main
+package gonad
 
 import (
 	"github.com/metaleap/go-util/dev/ps"
@@ -33,6 +33,9 @@ func (me *psCoreImp) forceAstIntoIrABlock(cia *udevps.CoreImpAst, into *irABlock
 }
 
 func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
+	if cia == nil { // purs never omits a required sub-node, but a mangled coreimp.json might
+		panic(notImplErr("CoreImp AST node", "null", me.My.ImpFilePath))
+	}
 	istopleveldecl := (cia.Parent == nil)
 	switch cia.AstTag {
 	case "StringLiteral":
@@ -102,22 +105,25 @@ func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
 			vlvb.parent = v
 			if v.LetVal != nil && vlvb.RefFunc != nil {
 				if istopleveldecl && ustr.BeginsUpper(cia.VariableIntroduction) {
-					wastypefunc = v.LetVal.(*irAFunc)
+					wastypefunc, _ = v.LetVal.(*irAFunc)
 				}
 			} else if vlvc, _ := v.LetVal.(*irACall); vlvc != nil {
 				if vlvcb := vlvc.Callee.Base(); vlvcb.RefFunc != nil {
 					if istopleveldecl && ustr.BeginsUpper(cia.VariableIntroduction) {
-						wastypefunc = vlvc.Callee.(*irAFunc)
+						wastypefunc, _ = vlvc.Callee.(*irAFunc)
 					}
 				}
 			}
 		}
 		if wastypefunc != nil {
-			a = &irACtor{irAFunc: *wastypefunc}
+			a = ªCtor(wastypefunc)
 		} else {
 			a = v
 		}
 	case "Function":
+		if istopleveldecl && cia.Function == "" {
+			panic(notImplErr("top-level Function", "nameless", me.My.ImpFilePath))
+		}
 		wastypefunc := istopleveldecl && cia.Function != "" && ustr.BeginsUpper(cia.Function)
 		f := ªFunc()
 		f.RefFunc = &irATypeRefFunc{}
@@ -130,7 +136,7 @@ func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
 		f.RefFunc.impl = f.FuncImpl
 		me.forceAstIntoIrABlock(cia.AstBody, f.FuncImpl)
 		if wastypefunc {
-			a = &irACtor{irAFunc: *f}
+			a = ªCtor(f)
 		} else {
 			a = f
 		}
@@ -191,7 +197,7 @@ func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
 		case "ShiftRight":
 			o.Op2 = ">>"
 		case "ZeroFillShiftRight":
-			o.Op2 = "&^"
+			o.Op2 = ">>>" // not Go, see irAOp2.goIntrinsic
 		default:
 			panic(notImplErr("Binary", o.Op2, cia.Root.My.ImpFilePath))
 		}
@@ -231,12 +237,14 @@ func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
 		o := ªSet(me.astToIrA(cia.Assignment), me.astToIrA(cia.AstRight))
 		a = o
 	case "Indexer":
-		if cia.AstRight.AstTag != "StringLiteral" {
+		if cia.Indexer == nil || cia.AstRight == nil {
+			panic(notImplErr("Indexer operand", "null", cia.Root.My.ImpFilePath))
+		} else if cia.AstRight.AstTag != "StringLiteral" {
 			a = ªIndex(me.astToIrA(cia.Indexer), me.astToIrA(cia.AstRight))
 		} else { // TODO will need to differentiate better between a real property or an obj-dict-key
 			if cia.Indexer.AstTag == "Var" {
-				if mod := findModuleByPName(cia.Indexer.Var); mod != nil {
-					a = ªPkgSym(mod.pName, cia.AstRight.StringLiteral)
+				if mod := me.mod.findModuleByPName(cia.Indexer.Var); mod != nil {
+					a = ªPkgSym(mod.pName, ustr.Upper.Ensure(cia.AstRight.StringLiteral, 0))
 				}
 			}
 			if a == nil {
@@ -245,11 +253,20 @@ func (me *psCoreImp) astToIrA(cia *udevps.CoreImpAst) (a irA) {
 			}
 		}
 	case "InstanceOf":
-		if cia.AstRight.Var != "" {
-			a = ªIs(me.astToIrA(cia.InstanceOf), cia.AstRight.Var)
+		if cia.AstRight == nil {
+			panic(notImplErr("InstanceOf right-hand-side", "null", cia.Root.My.ImpFilePath))
+		}
+		tested := me.astToIrA(cia.InstanceOf)
+		if _, issym := tested.(irASymStr); !issym {
+			panic(notImplErr("InstanceOf left-hand-side", cia.InstanceOf.AstTag, cia.Root.My.ImpFilePath))
+		} else if cia.AstRight.Var != "" {
+			a = ªIs(tested, cia.AstRight.Var)
 		} else if cia.AstRight.Indexer != nil {
-			apkgsym := me.astToIrA(cia.AstRight).(*irAPkgSym)
-			a = ªIs(me.astToIrA(cia.InstanceOf), findModuleByPName(apkgsym.PkgName).qName+"."+apkgsym.Symbol)
+			apkgsym, _ := me.astToIrA(cia.AstRight).(*irAPkgSym)
+			if apkgsym == nil {
+				panic(notImplErr("InstanceOf right-hand-side", "non-imported "+cia.AstRight.AstTag, cia.Root.My.ImpFilePath))
+			}
+			a = ªIs(tested, me.mod.findModuleByPName(apkgsym.PkgName).qName+"."+apkgsym.Symbol)
 		} else {
 			panic(notImplErr("InstanceOf right-hand-side", cia.AstRight.AstTag, cia.Root.My.ImpFilePath))
 		}
@@ -0,0 +1,125 @@
+package gonad
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+/*
+Concurrent sessions: every testdata/golden scenario gets
+compiled twice at once, each time by its own Compile call
+over its own temp-dir copy, and all of those run in parallel.
+Run with -race to catch any state still shared between
+sessions other than by design.
+*/
+
+const sessionsPerScenario = 2
+
+func TestSessionsConcurrently(t *testing.T) {
+	scenariodirpaths, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(scenariodirpaths) == 0 {
+		t.Fatal("no scenarios in testdata/golden")
+	}
+	ffipkgsdirpath, err := filepath.Abs(dirNameDefaultFfiPkgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("sessions", func(t *testing.T) { // returns only once all its parallel sub-tests are done
+		for _, dirpath := range scenariodirpaths {
+			for i := 0; i < sessionsPerScenario; i++ {
+				scenariodirpath := dirpath
+				t.Run(fmt.Sprintf("%s#%d", filepath.Base(scenariodirpath), i), func(t *testing.T) {
+					t.Parallel()
+					bowerfilepath, srcdirpath := goldenSetup(t, scenariodirpath)
+					depsdirpath := filepath.Join(filepath.Dir(bowerfilepath), "bower_components")
+					if err := os.Mkdir(depsdirpath, 0755); err != nil {
+						t.Fatal(err)
+					}
+					result, err := Compile(context.Background(), Options{SrcDirPath: srcdirpath, DepsDirPath: depsdirpath, BowerJsonFilePath: bowerfilepath,
+						NoPrefix: true, Build: goldenBuild, FfiPkgsPath: ffipkgsdirpath})
+					if err != nil {
+						t.Fatal(err)
+					} else if result.NumReGenerated == 0 || result.NumReGenerated != len(result.Modules) {
+						t.Errorf("re-generated %d of %d modules", result.NumReGenerated, len(result.Modules))
+					}
+					goldenCompare(t, filepath.Join(scenariodirpath, "want"), goldenOutputs(t, scenariodirpath, bowerfilepath))
+				})
+			}
+		}
+	})
+}
+
+// a stale gonad.json met in lazy mode gets re-generated in memory (and fully populated before any importer sees it), but not written: instead the session is flagged for Compile's non-lazy re-run
+func TestIrMetaLazyStale(t *testing.T) {
+	bowerfilepath, srcdirpath, _ := benchGenProject(t, benchGenOpts{NumMods: 3, NumImports: 2, NumAdts: 2, NumCtors: 2, NumClasses: 1, NumFuncs: 2, ClosureDepth: 2})
+	sess := benchLoadProj(t, bowerfilepath, srcdirpath)
+	for _, phase := range benchPhases {
+		if phase.name != "codegen" {
+			phase.run(&sess.proj)
+		}
+	}
+	broken := []byte("{")
+	benchWriteFile(t, sess.proj.Modules[0].irMetaFilePath, broken)
+
+	sess = benchLoadProj(t, bowerfilepath, srcdirpath)
+	sess.flag.ForceAll, sess.irMetasLoadLazily = false, true
+	var wait sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func(qname string) {
+			defer wait.Done()
+			if mod := sess.findModuleByQName(qname); mod == nil || len(mod.irMeta.GoTypeDefs) == 0 {
+				t.Errorf("%s: irMeta not populated", qname)
+			}
+		}(sess.proj.Modules[i%len(sess.proj.Modules)].qName)
+	}
+	wait.Wait()
+	if mod := sess.proj.Modules[0]; mod.irMetaStale == "" || atomic.LoadInt32(&sess.irMetasLazyStale) == 0 {
+		t.Errorf("broken gonad.json of %s not noticed", mod.qName)
+	} else if data, err := ioutil.ReadFile(mod.irMetaFilePath); err != nil || string(data) != string(broken) {
+		t.Errorf("broken gonad.json of %s re-written before its .go file: %v", mod.qName, err)
+	}
+	for _, mod := range sess.proj.Modules[1:] {
+		if mod.irMetaStale != "" {
+			t.Errorf("intact gonad.json of %s considered stale: %s", mod.qName, mod.irMetaStale)
+		}
+	}
+}
+
+// the gonad build stamped into gonad.json comes from the go tool's build info where that has a version, else (eg. for GOPATH-mode builds) from hashing the executable
+func TestGonadBuildOfBuildInfo(t *testing.T) {
+	mainmod := func(version string, settings ...debug.BuildSetting) *debug.BuildInfo {
+		return &debug.BuildInfo{Main: debug.Module{Path: gonadModPath, Version: version}, Settings: settings}
+	}
+	for want, info := range map[string]*debug.BuildInfo{
+		"abc123+modified": mainmod("(devel)", debug.BuildSetting{Key: "vcs.revision", Value: "abc123"}, debug.BuildSetting{Key: "vcs.modified", Value: "true"}),
+		"v1.2.3":          mainmod("v1.2.3"),
+		"v0.4.0":          {Main: debug.Module{Path: "example.com/tool", Version: "(devel)"}, Deps: []*debug.Module{{Path: gonadModPath, Version: "v0.4.0"}}},
+	} {
+		if got := gonadBuildOfBuildInfo(info); got != want {
+			t.Errorf("want %s, got %s", want, got)
+		}
+	}
+	for _, info := range []*debug.BuildInfo{
+		nil,
+		{Path: "github.com/metaleap/gonad/cmd/gonad"}, // GOPATH mode: no module info at all
+		mainmod("(devel)"),
+		{Main: debug.Module{Path: "example.com/tool"}, Deps: []*debug.Module{{Path: gonadModPath, Version: "v0.4.0", Replace: &debug.Module{Path: "../gonad"}}}},
+	} {
+		if got := gonadBuildOfBuildInfo(info); got != gonadBuildExeHash {
+			t.Errorf("want %s, got %s", gonadBuildExeHash, got)
+		}
+	}
+	if exehash := gonadBuildOfExe(); exehash == "dev" || exehash != gonadBuildOfExe() {
+		t.Errorf("no stable hash of the test executable: %s", exehash)
+	}
+}
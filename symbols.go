@@ -0,0 +1,308 @@
+package gonad
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/metaleap/go-util/fs"
+)
+
+/*
+The gonad-symbols.json written into each project's Go output
+dir: for every exported PureScript value, type, constructor
+and type-class of its modules, the Go import path and Go
+identifier it ended up as (after sanitizeSymbolForGo, the
+ᛌ/۰/ˆ/ˇ suffixes etc.), along with both its Go and PS types.
+All synthesized from the GoTypeDefs and GoValDecls (plus the
+Env* decls they came from) of each module's irMeta, for Go
+callers and editor tooling that would otherwise have to guess.
+
+Data constructors are listed with the Go type to instantiate,
+newtype constructors with the Go type to convert to. Modules
+that failed have no symbols to list, just their names under
+Failed, so that nobody mistakes them for removed ones.
+*/
+
+const symbolsFileName = "gonad-symbols.json"
+
+type symbolsFile struct {
+	Project string
+	Modules []*symbolsModule // sorted by PsModQName
+	Failed  []string         `json:",omitempty"` // the PsModQNames of the modules left out for having failed (see Result), sorted
+}
+
+type symbolsModule struct {
+	PsModQName string // eg. Data.Maybe
+	GoName     string // the Go package name, eg. DataꓸMaybe
+	ImpPath    string // the Go import path
+	Symbols    []*symbolsEntry
+}
+
+type symbolsEntry struct {
+	Kind     string // "class", "type", "ctor" or "value"
+	NamePs   string
+	NameGo   string
+	TypePs   string // in PureScript syntax, with all but Prim types module-qualified
+	TypeGo   string // as within the module's own Go package: its own types unqualified, others qualified by GoName
+	DataType string `json:",omitempty"` // for ctors, the NamePs of their type
+}
+
+// writeSymbolsFile (re)writes our gonad-symbols.json, unless it's known to be current already: then, its modules' irMetas don't even need loading
+func (me *psBowerProject) writeSymbolsFile() {
+	symfilepath := filepath.Join(me.sess.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, me.GoOut.PkgDirPath, symbolsFileName)
+	if me.sess.irMetasLoadLazily && !me.sess.flag.ForceAll && ufs.FileExists(symfilepath) {
+		uptodate := true
+		for _, m := range me.Modules {
+			uptodate = uptodate && !(m.reGenIr || m.failed())
+		}
+		if uptodate && me.symbolsFileHasAllModules(symfilepath) {
+			return
+		}
+	}
+	var mutex sync.Mutex
+	symfile := &symbolsFile{Project: me.BowerJsonFile.Name, Modules: make([]*symbolsModule, 0, len(me.Modules))}
+	me.forAll(func(m *modPkg) {
+		m.ensureIrMeta()
+		symmod := m.symbols()
+		mutex.Lock()
+		defer mutex.Unlock()
+		symfile.Modules = append(symfile.Modules, symmod)
+	})
+	for _, m := range me.Modules {
+		if m.failed() {
+			symfile.Failed = append(symfile.Failed, m.qName)
+		}
+	}
+	sort.Strings(symfile.Failed)
+	sort.Slice(symfile.Modules, func(i int, j int) bool { return symfile.Modules[i].PsModQName < symfile.Modules[j].PsModQName })
+	var buf bytes.Buffer
+	jsonenc := json.NewEncoder(&buf)
+	jsonenc.SetEscapeHTML(false)
+	jsonenc.SetIndent("", "\t")
+	if err := jsonenc.Encode(symfile); err != nil {
+		panic(err)
+	} else if err = ufs.WriteBinaryFile(symfilepath, buf.Bytes()); err != nil {
+		panic(err)
+	}
+}
+
+// symbolsFileHasAllModules is true if the existing gonad-symbols.json lists exactly our modules, none having been added or removed since
+func (me *psBowerProject) symbolsFileHasAllModules(symfilepath string) bool {
+	var symfile struct {
+		Modules []struct{ PsModQName string }
+		Failed  []string
+	}
+	if err := jsonDecodeFileStreamed(symfilepath, &symfile, "Modules", "Failed"); err != nil || len(symfile.Failed) > 0 || len(symfile.Modules) != len(me.Modules) {
+		return false
+	}
+	for _, symmod := range symfile.Modules {
+		if m := me.sess.modPkgByQName(symmod.PsModQName); m == nil || m.proj != me {
+			return false
+		}
+	}
+	return true
+}
+
+// symbols lists our exported symbols in the order of their Env* decls: type-classes, type synonyms, data types (each followed by its ctors), then values
+func (me *modPkg) symbols() (symmod *symbolsModule) {
+	irM, irast := me.irMeta, &irAst{mod: me, irM: me.irMeta}
+	symmod = &symbolsModule{PsModQName: me.qName, GoName: me.pName, ImpPath: me.impPath(), Symbols: []*symbolsEntry{}}
+	add := func(kind string, nameps string, typeps string, gtd *irANamedTypeRef) *symbolsEntry {
+		sym := &symbolsEntry{Kind: kind, NamePs: nameps, TypePs: typeps}
+		if gtd != nil {
+			sig := gtd.nameless()
+			if gtd.RefFunc != nil {
+				sig.RefFunc = gtd.RefFunc.toSig(false) // as gtd might be linked to its implementation
+			}
+			sym.NameGo = gtd.NameGo
+			sym.TypeGo, _ = irast.codeGenTypeRefDetached(sig)
+		}
+		symmod.Symbols = append(symmod.Symbols, sym)
+		return sym
+	}
+
+	for _, tc := range irM.EnvTypeClasses {
+		if irM.hasExport(tc.Name) {
+			add("class", tc.Name, tc.psString(), irM.goTypeDefByGoName(sanitizeSymbolForGo(tc.Name, true)+"ᛌ"))
+		}
+	}
+	for _, ts := range irM.EnvTypeSyns {
+		if irM.hasExport(ts.Name) && irM.tc(ts.Name) == nil {
+			add("type", ts.Name, ts.Ref.psString(), irM.goTypeDefByGoName(sanitizeSymbolForGo(ts.Name, true)))
+		}
+	}
+	for _, td := range irM.EnvTypeDataDecls {
+		if irM.hasExport(td.Name) {
+			gid := irM.goTypeDefByGoName(sanitizeSymbolForGo(td.Name, true))
+			add("type", td.Name, td.psString(), gid)
+			for _, ctor := range td.Ctors {
+				if irM.hasExport(td.Name + "ĸ" + ctor.Name) {
+					gtd := irM.goTypeDefByGoName(sanitizeSymbolForGo(td.Name+"۰"+ctor.Name, true))
+					if gtd == nil { // a newtype
+						gtd = gid
+					}
+					add("ctor", ctor.Name, ctor.psString(me.qName, td), gtd).DataType = td.Name
+				}
+			}
+		}
+	}
+	for _, evd := range irM.EnvValDecls {
+		if irM.hasExport(evd.Name) {
+			gvd := irM.goValDeclByPsName(evd.Name)
+			if tcm := irM.tcMember(evd.Name); tcm != nil && gvd != nil {
+				gvd = irM.tcMemberSig(tcm, gvd)
+			}
+			add("value", evd.Name, evd.Ref.psString(), gvd)
+		}
+	}
+	return
+}
+
+// tcMemberSig is the Go signature that postLinkUpTcMemberFuncs gives the accessor func of a type-class member, as its GoValDecl only carries it for members of func type
+func (me *irMeta) tcMemberSig(tcm *irMTypeClassMember, gvd *irANamedTypeRef) *irANamedTypeRef {
+	gtd := me.goTypeDefByPsName(tcm.tc.Name)
+	if gtd == nil || gtd.RefStruct == nil {
+		return gvd
+	}
+	field := gtd.RefStruct.Fields.byPsName(tcm.Name)
+	if field == nil {
+		return gvd
+	}
+	dictarg := &irANamedTypeRef{RefAlias: gtd.NamePs}
+	if gtd.RefStruct.PassByPtr {
+		dictarg.turnRefIntoRefPtr()
+	}
+	return &irANamedTypeRef{NamePs: gvd.NamePs, NameGo: gvd.NameGo, RefFunc: &irATypeRefFunc{Args: irANamedTypeRefs{dictarg}, Rets: irANamedTypeRefs{field.nameless()}}}
+}
+
+// the precedence levels of PureScript type syntax, for psString to know where parens are needed
+const (
+	psTypePrecAtom = iota
+	psTypePrecApp
+	psTypePrecFunc
+	psTypePrecForAll
+)
+
+// psString renders a type in PureScript syntax, with all type names module-qualified except those in Prim
+func (me *irMTypeRef) psString() string {
+	s, _ := me.psStringPrec()
+	return s
+}
+
+// psStringAt renders a type to appear where types of at most precedence maxprec may, parenthesizing it otherwise
+func (me *irMTypeRef) psStringAt(maxprec int) string {
+	if s, prec := me.psStringPrec(); prec <= maxprec {
+		return s
+	} else {
+		return "(" + s + ")"
+	}
+}
+
+func (me *irMTypeRef) psStringPrec() (string, int) {
+	switch {
+	case me == nil:
+		return "?", psTypePrecAtom
+	case me.TypeConstructor != "":
+		return strings.TrimPrefix(me.TypeConstructor, "Prim."), psTypePrecAtom
+	case me.TypeVar != "":
+		return me.TypeVar, psTypePrecAtom
+	case me.Skolem != nil:
+		return me.Skolem.Name, psTypePrecAtom
+	case me.REmpty:
+		return "()", psTypePrecAtom
+	case me.RCons != nil:
+		return "(" + me.psStringRow() + ")", psTypePrecAtom
+	case me.ForAll != nil:
+		tvars, ref := []string{}, me
+		for ; ref != nil && ref.ForAll != nil; ref = ref.ForAll.Ref {
+			tvars = append(tvars, ref.ForAll.Name)
+		}
+		return "forall " + strings.Join(tvars, " ") + ". " + ref.psStringAt(psTypePrecForAll), psTypePrecForAll
+	case me.ConstrainedType != nil:
+		return me.ConstrainedType.psString() + " => " + me.ConstrainedType.Ref.psStringAt(psTypePrecForAll), psTypePrecForAll
+	case me.TypeApp != nil:
+		var args irMTypeRefs
+		head := me
+		for ; head != nil && head.TypeApp != nil; head = head.TypeApp.Left {
+			args = append(irMTypeRefs{head.TypeApp.Right}, args...)
+		}
+		switch {
+		case head == nil:
+		case head.TypeConstructor == "Prim.Function" && len(args) == 2:
+			return args[0].psStringAt(psTypePrecApp) + " -> " + args[1].psStringAt(psTypePrecFunc), psTypePrecFunc
+		case head.TypeConstructor == "Prim.Record" && len(args) == 1 && (args[0].RCons != nil || args[0].REmpty):
+			if args[0].REmpty {
+				return "{}", psTypePrecAtom
+			}
+			return "{ " + args[0].psStringRow() + " }", psTypePrecAtom
+		}
+		s := head.psStringAt(psTypePrecApp)
+		for _, arg := range args {
+			s += " " + arg.psStringAt(psTypePrecAtom)
+		}
+		return s, psTypePrecApp
+	}
+	return "?", psTypePrecAtom
+}
+
+// psStringRow renders the labels of an RCons row (without the surrounding parens or braces), along with its tail unless empty
+func (me *irMTypeRef) psStringRow() string {
+	var labels []string
+	tail := me
+	for ; tail != nil && tail.RCons != nil; tail = tail.RCons.Right {
+		labels = append(labels, tail.RCons.Label+" :: "+tail.RCons.Left.psString())
+	}
+	if s := strings.Join(labels, ", "); tail == nil || tail.REmpty {
+		return s
+	} else {
+		return s + " | " + tail.psString()
+	}
+}
+
+func (me *irMTypeRefConstr) psString() string {
+	s := strings.TrimPrefix(me.Class, "Prim.")
+	for _, arg := range me.Args {
+		s += " " + arg.psStringAt(psTypePrecAtom)
+	}
+	return s
+}
+
+// psString renders the class head, along with its super-classes (if any) in PureScript's `<=` notation
+func (me *irMTypeClass) psString() (s string) {
+	if len(me.Constraints) > 0 {
+		supers := make([]string, 0, len(me.Constraints))
+		for _, constr := range me.Constraints {
+			supers = append(supers, constr.psString())
+		}
+		if s = strings.Join(supers, ", "); len(supers) > 1 {
+			s = "(" + s + ")"
+		}
+		s += " <= "
+	}
+	return s + strings.Join(append([]string{me.Name}, me.Args...), " ")
+}
+
+// psString renders the data type's ctors as in its `data` declaration
+func (me *irMTypeDataDecl) psString() string {
+	ctors := make([]string, 0, len(me.Ctors))
+	for _, ctor := range me.Ctors {
+		s := ctor.Name
+		for _, arg := range ctor.Args {
+			s += " " + arg.psStringAt(psTypePrecAtom)
+		}
+		ctors = append(ctors, s)
+	}
+	return strings.Join(ctors, " | ")
+}
+
+// psString renders the ctor's type as a function from its args to its data type (declared in module modqname)
+func (me *irMTypeDataCtor) psString(modqname string, td *irMTypeDataDecl) (s string) {
+	for _, arg := range me.Args {
+		s += arg.psStringAt(psTypePrecApp) + " -> "
+	}
+	return s + strings.Join(append([]string{modqname + "." + td.Name}, td.Args...), " ")
+}
@@ -0,0 +1,97 @@
+package gonad
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func psTestApp(head *irMTypeRef, args ...*irMTypeRef) *irMTypeRef {
+	for _, arg := range args {
+		head = &irMTypeRef{TypeApp: &irMTypeRefAppl{Left: head, Right: arg}}
+	}
+	return head
+}
+
+func psTestFn(arg *irMTypeRef, ret *irMTypeRef) *irMTypeRef {
+	return psTestApp(&irMTypeRef{TypeConstructor: "Prim.Function"}, arg, ret)
+}
+
+func psTestRow(tail *irMTypeRef, labels ...string) *irMTypeRef {
+	for i := len(labels) - 1; i >= 0; i-- {
+		tail = &irMTypeRef{RCons: &irMTypeRefRow{Label: labels[i], Left: &irMTypeRef{TypeConstructor: "Prim.Int"}, Right: tail}}
+	}
+	return tail
+}
+
+func TestPsStringPrec(t *testing.T) {
+	a, b, r := &irMTypeRef{TypeVar: "a"}, &irMTypeRef{TypeVar: "b"}, &irMTypeRef{TypeVar: "r"}
+	maybe, record := &irMTypeRef{TypeConstructor: "Data.Maybe.Maybe"}, &irMTypeRef{TypeConstructor: "Prim.Record"}
+	showa := &irMTypeRef{ConstrainedType: &irMTypeRefConstr{Class: "Data.Show.Show", Args: irMTypeRefs{a}, Ref: psTestFn(a, &irMTypeRef{TypeConstructor: "Prim.String"})}}
+	forall := &irMTypeRef{ForAll: &irMTypeRefExist{Name: "a", Ref: &irMTypeRef{ForAll: &irMTypeRefExist{Name: "b", Ref: psTestFn(a, b)}}}}
+	for want, tref := range map[string]*irMTypeRef{
+		"a -> b -> a":          psTestFn(a, psTestFn(b, a)),
+		"(a -> b) -> a":        psTestFn(psTestFn(a, b), a),
+		"((a -> b) -> a) -> b": psTestFn(psTestFn(psTestFn(a, b), a), b),
+		"Data.Maybe.Maybe a -> Data.Maybe.Maybe b": psTestFn(psTestApp(maybe, a), psTestApp(maybe, b)),
+		"Data.Maybe.Maybe (Data.Maybe.Maybe a)":    psTestApp(maybe, psTestApp(maybe, a)),
+		"Data.Maybe.Maybe (a -> b)":                psTestApp(maybe, psTestFn(a, b)),
+		"Data.Show.Show a => a -> String":          showa,
+		"(Data.Show.Show a => a -> String) -> a":   psTestFn(showa, a),
+		"a -> (Data.Show.Show a => a -> String)":   psTestFn(a, showa),
+		"forall a b. a -> b":                       forall,
+		"(forall a b. a -> b) -> a":                psTestFn(forall, a),
+		"Data.Maybe.Maybe (forall a b. a -> b)":    psTestApp(maybe, forall),
+		"{ x :: Int, y :: Int }":                   psTestApp(record, psTestRow(&irMTypeRef{REmpty: true}, "x", "y")),
+		"{ x :: Int | r } -> r":                    psTestFn(psTestApp(record, psTestRow(r, "x")), r),
+		"{}":                                       psTestApp(record, &irMTypeRef{REmpty: true}),
+		"Data.Maybe.Maybe { x :: Int }":            psTestApp(maybe, psTestApp(record, psTestRow(&irMTypeRef{REmpty: true}, "x"))),
+		"Record r":                                 psTestApp(record, r),
+		"Data.Foo (x :: Int | r)":                  psTestApp(&irMTypeRef{TypeConstructor: "Data.Foo"}, psTestRow(r, "x")),
+		"{ f :: a -> b }":                          psTestApp(record, &irMTypeRef{RCons: &irMTypeRefRow{Label: "f", Left: psTestFn(a, b), Right: &irMTypeRef{REmpty: true}}}),
+	} {
+		if got := tref.psString(); got != want {
+			t.Errorf("want `%s`, got `%s`", want, got)
+		}
+	}
+}
+
+// a lazy run must re-write gonad-symbols.json once modules got removed, even if none got re-generated, and must list those that failed
+func TestSymbolsFileModules(t *testing.T) {
+	bowerfilepath, srcdirpath, outdirpath := benchGenProject(t, benchGenOpts{NumMods: 3, NumImports: 1, NumAdts: 1, NumCtors: 2, NumClasses: 1, NumFuncs: 2, ClosureDepth: 2})
+	sess := benchLoadProj(t, bowerfilepath, srcdirpath)
+	for _, phase := range benchPhases {
+		if phase.name != "codegen" {
+			phase.run(&sess.proj)
+		}
+	}
+	symfilepath := filepath.Join(sess.proj.BowerJsonFile.Gonad.Out.GoDirSrcPath, sess.proj.GoOut.PkgDirPath, symbolsFileName)
+	if !sess.proj.symbolsFileHasAllModules(symfilepath) {
+		t.Fatal("freshly written gonad-symbols.json not considered current")
+	}
+	readsymfile := func() (symfile symbolsFile) {
+		if err := json.Unmarshal(goldenReadFile(t, symfilepath), &symfile); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	if err := os.Remove(filepath.Join(srcdirpath, "Bench", "M2.purs")); err != nil {
+		t.Fatal(err)
+	} else if err = os.RemoveAll(filepath.Join(outdirpath, "Bench.M2")); err != nil {
+		t.Fatal(err)
+	}
+	sess = benchLoadProj(t, bowerfilepath, srcdirpath)
+	sess.flag.ForceAll, sess.irMetasLoadLazily = false, true
+	if sess.proj.writeSymbolsFile(); len(readsymfile().Modules) != 2 {
+		t.Errorf("removed module still in gonad-symbols.json: %v", readsymfile().Modules)
+	}
+
+	sess.proj.Modules[1].diags = append(sess.proj.Modules[1].diags, &Diagnostic{Module: sess.proj.Modules[1].qName, Message: "failed"})
+	if sess.proj.writeSymbolsFile(); len(readsymfile().Modules) != 1 || len(readsymfile().Failed) != 1 || readsymfile().Failed[0] != sess.proj.Modules[1].qName {
+		t.Errorf("failed module not listed as such in gonad-symbols.json: %v", readsymfile().Failed)
+	} else if sess.proj.symbolsFileHasAllModules(symfilepath) {
+		t.Error("gonad-symbols.json with failed modules considered current")
+	}
+}
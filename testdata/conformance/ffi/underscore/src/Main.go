@@ -0,0 +1,6 @@
+package ffi
+
+import "strings"
+
+// Ʊshout implements foreign import `_shout`.
+func Ʊshout(s string) string { return strings.ToUpper(s) + "!" }
@@ -0,0 +1,5 @@
+"use strict";
+
+exports._shout = function (s) {
+  return s.toUpperCase() + "!";
+};
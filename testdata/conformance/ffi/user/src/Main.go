@@ -0,0 +1,6 @@
+package ffi
+
+import "strings"
+
+// Shout implements foreign import `shout`.
+func Shout(s string) string { return strings.ToUpper(s) + "!" }
@@ -0,0 +1,5 @@
+"use strict";
+
+exports.shout = function (s) {
+  return s.toUpperCase() + "!";
+};
@@ -0,0 +1,8 @@
+"use strict";
+
+exports.log = function (s) {
+  return function () {
+    console.log(s);
+    return {};
+  };
+};
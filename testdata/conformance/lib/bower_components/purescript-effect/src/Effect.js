@@ -0,0 +1,15 @@
+"use strict";
+
+exports.pureE = function (a) {
+  return function () {
+    return a;
+  };
+};
+
+exports.bindE = function (a) {
+  return function (f) {
+    return function () {
+      return f(a())();
+    };
+  };
+};
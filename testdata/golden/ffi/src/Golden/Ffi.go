@@ -0,0 +1,9 @@
+package ffi
+
+import "strings"
+
+// Shout implements foreign import `shout`.
+func Shout(s string) string { return strings.ToUpper(s) + "!" }
+
+// Limit implements foreign import `limit`.
+var Limit int32 = 42
@@ -1,8 +1,12 @@
-package main
+package gonad
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"unicode"
 
@@ -14,9 +18,12 @@ import (
 type never struct{}
 
 const (
-	prefixDefaultFfiPkgImpPath = "github.com/gonadz/-/ffi/ps2go/"
+	prefixDefaultFfiPkgImpPath = "github.com/gonadz/g/ffi/ps2go/"
 	prefixDefaultFfiPkgNs      = "𝙜ˈ"
 	msgfmt                     = "encountered un-anticipated %s '%s' in %v,\n\tplease report the case with the *.purs code(base) so that I can support it"
+
+	strReprUtf8  = "utf8"
+	strReprUtf16 = "utf16"
 )
 
 var (
@@ -31,7 +38,8 @@ var (
 	strReplSanitizer  = strings.NewReplacer("'", "ˈ", "$", "ᵒ")
 	strReplUnsanitize = strings.NewReplacer("$prime", "'", "$$", "")
 
-	_symcounter = 0
+	// both the current "at Mod (line 1, column 2 - line 3, column 4): " and the older paren-less form
+	rxPatMatchFailMsg = regexp.MustCompile(`^Failed pattern match at (\S+) \(?line (\d+), column (\d+) - line (\d+), column (\d+)\)?: ?$`)
 )
 
 func init() {
@@ -39,10 +47,22 @@ func init() {
 	udevps.StrReplUnsanitize = strReplUnsanitize
 }
 
+// notImplError is the structured diagnostic for inputs gonad does not (yet) handle, as opposed to crashes from bugs in gonad itself
+type notImplError struct {
+	Cat  string
+	Name string
+	In   interface{}
+}
+
+func (me *notImplError) Error() string {
+	return fmt.Sprintf(msgfmt, me.Cat, me.Name, me.In)
+}
+
 func notImplErr(cat string, name string, in interface{}) error {
-	return fmt.Errorf(msgfmt, cat, name, in)
+	return &notImplError{Cat: cat, Name: name, In: in}
 }
 
+// panicWithType is for internal assertions, ie. bugs in gonad: unsupported inputs get a notImplErr instead
 func panicWithType(in string, v interface{}, of string) {
 	panic(fmt.Errorf("%s: unexpected value %v (type %v) for '%s'", in, v, reflect.TypeOf(v), of))
 }
@@ -54,12 +74,12 @@ func ensureIfaceForTvar(tdict map[string][]string, tvar string, ifacetname strin
 	}
 }
 
-func findPsTypeByQName(qname string) (mod *modPkg, tr interface{}) {
+func (me *session) findPsTypeByQName(qname string) (mod *modPkg, tr interface{}) {
 	var pname, tname string
 	i := strings.LastIndex(qname, ".")
 	if tname = qname[i+1:]; i > 0 {
 		pname = qname[:i]
-		if mod = findModuleByQName(pname); mod == nil {
+		if mod = me.findModuleByQName(pname); mod == nil {
 			panic(notImplErr("module qname", pname, qname))
 		} else {
 			for _, ets := range mod.irMeta.EnvTypeSyns {
@@ -95,7 +115,7 @@ func findPsTypeByQName(qname string) (mod *modPkg, tr interface{}) {
 
 func findGoTypeByGoQName(curmod *modPkg, qname string) (mod *modPkg, tref *irANamedTypeRef) {
 	pname, tname := ustr.SplitOnce(qname, '.')
-	if mod = findModuleByPName(pname); mod == nil {
+	if mod = curmod.findModuleByPName(pname); mod == nil {
 		mod = curmod
 	}
 	tref = mod.irMeta.goTypeDefByGoName(tname)
@@ -107,8 +127,8 @@ func findGoTypeByPsQName(curmod *modPkg, qname string) (*modPkg, *irANamedTypeRe
 	mod, i := curmod, strings.LastIndex(qname, ".")
 	if tname = qname[i+1:]; i > 0 {
 		pname = qname[:i]
-		if mod = findModuleByQName(pname); mod == nil {
-			mod = findModuleByPName(pname)
+		if mod = curmod.findModuleByQName(pname); mod == nil {
+			mod = curmod.findModuleByPName(pname)
 		}
 		if mod == nil {
 			if pname == "Prim" {
@@ -121,6 +141,11 @@ func findGoTypeByPsQName(curmod *modPkg, qname string) (*modPkg, *irANamedTypeRe
 	return mod, mod.irMeta.goTypeDefByPsName(tname)
 }
 
+// irAIsNil also catches the (rare) typed nil pointers in irA fields, see walk
+func irAIsNil(a irA) bool {
+	return a == nil || reflect.ValueOf(a).IsNil()
+}
+
 func irASymStrOr(me irA, or string) string {
 	if asymstr, _ := me.(irASymStr); asymstr != nil {
 		return asymstr.symStr()
@@ -159,3 +184,136 @@ func typeNameWithPkgName(pkgname string, typename string) (fullname string) {
 	}
 	return
 }
+
+// jsonDecodeFileStreamed decodes the JSON object in the file at filepath into the struct pointed to by into (see jsonDecodeStreamed)
+func jsonDecodeFileStreamed(filepath string, into interface{}, only ...string) (err error) {
+	var f *os.File
+	if f, err = os.Open(filepath); err == nil {
+		defer f.Close()
+		if err = jsonDecodeStreamed(f, into, only...); err != nil {
+			err = fmt.Errorf("%s: %v", filepath, err)
+		}
+	}
+	return
+}
+
+// jsonDecodeStreamed decodes the JSON object in r into the struct pointed to by into, top-level field by top-level field, so that never more than one of them is buffered in full: and for slice fields (such as a coreimp.json's whole module body), never more than one of their elements. Fields not in the struct (or, if any are given, not in only) are skipped token by token without being buffered or decoded.
+func jsonDecodeStreamed(r io.Reader, into interface{}, only ...string) (err error) {
+	dec, v := json.NewDecoder(r), reflect.ValueOf(into).Elem()
+	if err = jsonExpectDelim(dec, '{'); err != nil {
+		return
+	}
+	for dec.More() {
+		var tok json.Token
+		if tok, err = dec.Token(); err != nil {
+			return
+		}
+		key, _ := tok.(string)
+		if fv := jsonStructField(v, key); !(fv.IsValid() && (len(only) == 0 || jsonKeyIn(key, only))) {
+			err = jsonSkipValue(dec)
+		} else if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 && !fv.Addr().Type().Implements(jsonUnmarshalerType) { // not []byte, which is a base64 string in JSON
+			err = jsonDecodeSliceStreamed(dec, fv)
+		} else {
+			err = dec.Decode(fv.Addr().Interface())
+		}
+		if err != nil {
+			return
+		}
+	}
+	return jsonExpectDelim(dec, '}')
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// jsonDecodeSliceStreamed decodes the JSON array (or null) next in dec into the slice sv, element by element
+func jsonDecodeSliceStreamed(dec *json.Decoder, sv reflect.Value) (err error) {
+	var tok json.Token
+	if tok, err = dec.Token(); err != nil {
+		return
+	} else if tok == nil {
+		sv.Set(reflect.Zero(sv.Type()))
+		return
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("expected '[' but got: %v", tok)
+	}
+	sv.Set(reflect.MakeSlice(sv.Type(), 0, 0))
+	for dec.More() {
+		elem := reflect.New(sv.Type().Elem())
+		if err = dec.Decode(elem.Interface()); err != nil {
+			return
+		}
+		sv.Set(reflect.Append(sv, elem.Elem()))
+	}
+	return jsonExpectDelim(dec, ']')
+}
+
+func jsonKeyIn(key string, names []string) bool {
+	for _, name := range names {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonExpectDelim(dec *json.Decoder, delim json.Delim) error {
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != delim {
+		return fmt.Errorf("expected '%v' but got: %v", delim, tok)
+	}
+	return nil
+}
+
+// jsonStructField finds the (possibly promoted) field that encoding/json would decode the given object key into, or returns the zero Value.
+// Like there, shallower fields shadow deeper ones of the same name, and an exact name match wins over a case-insensitive one.
+func jsonStructField(v reflect.Value, key string) (fold reflect.Value) {
+	shadowed := map[string]bool{}
+	for level := []reflect.Value{v}; len(level) > 0; {
+		var embedded []reflect.Value
+		names := map[string]bool{}
+		for _, sv := range level {
+			for i, t := 0, sv.Type(); i < t.NumField(); i++ {
+				f, name := t.Field(i), strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+				if f.Anonymous && f.Type.Kind() == reflect.Struct && name == "" {
+					embedded = append(embedded, sv.Field(i))
+				} else if f.PkgPath == "" && name != "-" {
+					if name == "" {
+						name = f.Name
+					}
+					if names[name] = true; shadowed[name] {
+						continue
+					} else if name == key {
+						return sv.Field(i)
+					} else if !fold.IsValid() && strings.EqualFold(name, key) {
+						fold = sv.Field(i)
+					}
+				}
+			}
+		}
+		for name := range names {
+			shadowed[name] = true
+		}
+		level = embedded
+	}
+	return
+}
+
+// jsonSkipValue consumes the next value from dec without buffering it in full
+func jsonSkipValue(dec *json.Decoder) error {
+	for depth := 0; ; {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
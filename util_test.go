@@ -0,0 +1,59 @@
+package gonad
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jsonTestInner struct {
+	Name  string
+	Inner int `json:"deep"`
+}
+
+type jsonTestOuter struct {
+	jsonTestInner
+	Name   string // shadows jsonTestInner.Name
+	Kind   string `json:"kind"`
+	Nums   []int
+	Items  []jsonTestInner
+	None   []int
+	Empty  []string
+	Hidden string `json:"-"`
+	Nested struct{ A, B int }
+}
+
+func TestJsonDecodeStreamed(t *testing.T) {
+	const src = `{"name": "outer", "Kind": "folded", "kind": "exact", "deep": 42, "skipped": {"x": [1, {"y": [[]]}], "z": "}"},
+		"NUMS": [1, 2, 3], "Hidden": "no", "nested": {"A": 1, "b": 2},
+		"items": [{"name": "a", "deep": 1}, {"deep": 2}], "None": null, "Empty": [], "trailing": [{}, []]}`
+
+	//	all fields, same as encoding/json
+	var got, want jsonTestOuter
+	if err := jsonDecodeStreamed(strings.NewReader(src), &got); err != nil {
+		t.Fatal(err)
+	} else if err = json.Unmarshal([]byte(src), &want); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	} else if got.Name != "outer" || got.jsonTestInner.Name != "" || got.Kind != "exact" || got.Inner != 42 || len(got.Nums) != 3 || got.Nested.B != 2 || len(got.Items) != 2 || got.Items[1].Inner != 2 || got.None != nil || got.Empty == nil {
+		t.Errorf("unexpected %#v", got)
+	}
+
+	//	just some fields, the others skipped
+	var only jsonTestOuter
+	if err := jsonDecodeStreamed(strings.NewReader(src), &only, "Nums", "DEEP"); err != nil {
+		t.Fatal(err)
+	} else if only.Name != "" || only.Kind != "" || only.Inner != 42 || len(only.Nums) != 3 || only.Nested.A != 0 {
+		t.Errorf("unexpected %#v", only)
+	}
+
+	//	broken inputs, also in skipped sections
+	for _, bad := range []string{`[]`, `{"skipped": [1, 2}`, `{"Nums": [1, 2]`, `{"skipped": {"x": 1}`, `{"Nums": [1, "2"]}`, `{"Nums": 1}`, `{"Items": [{}, {]}`} {
+		var into jsonTestOuter
+		if err := jsonDecodeStreamed(strings.NewReader(bad), &into); err == nil {
+			t.Errorf("no error for %s", bad)
+		}
+	}
+}
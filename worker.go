@@ -1,6 +1,7 @@
-package main
+package gonad
 
 import (
+	"context"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -10,57 +11,50 @@ import (
 
 type mainWorker struct {
 	sync.WaitGroup
+	sess *session
 }
 
-func (me *mainWorker) forAllDeps(fn func(*psBowerProject)) {
-	for _, d := range Deps {
+// forAllDeps runs fn on all deps in parallel, returning the first error any of them panicked with (or ctx's, if done before)
+func (me *mainWorker) forAllDeps(ctx context.Context, fn func(*psBowerProject)) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var mutex sync.Mutex
+	for _, d := range me.sess.deps {
 		me.Add(1)
-		go fn(d)
+		go func(dep *psBowerProject) {
+			defer me.Done()
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					mutex.Lock()
+					if err == nil {
+						err = newDiagnostic("", recovered)
+					}
+					mutex.Unlock()
+				}
+			}()
+			fn(dep)
+		}(d)
 	}
 	me.Wait()
+	return
 }
 
 func (me *mainWorker) checkIfDepDirHasBowerFile(locker sync.Locker, reldirpath string) {
 	defer me.Done()
 	jsonfilepath := filepath.Join(reldirpath, ".bower.json")
-	if depname := strings.TrimLeft(reldirpath[len(Proj.DepsDirPath):], "\\/"); ufs.FileExists(jsonfilepath) {
+	if depname := strings.TrimLeft(reldirpath[len(me.sess.proj.DepsDirPath):], "\\/"); ufs.FileExists(jsonfilepath) {
 		bproj := &psBowerProject{
-			DepsDirPath: Proj.DepsDirPath, BowerJsonFilePath: jsonfilepath, SrcDirPath: filepath.Join(reldirpath, "src"),
+			DepsDirPath: me.sess.proj.DepsDirPath, BowerJsonFilePath: jsonfilepath, SrcDirPath: filepath.Join(reldirpath, "src"), sess: me.sess,
 		}
 		defer locker.Unlock()
 		locker.Lock()
-		Deps[depname] = bproj
+		me.sess.deps[depname] = bproj
 	}
 }
 
 func (me *mainWorker) loadDepFromBowerFile(dep *psBowerProject) {
-	defer me.Done()
 	if err := dep.loadFromJsonFile(); err != nil {
 		panic(err)
 	}
 }
-
-func (me *mainWorker) loadIrMetas(dep *psBowerProject) {
-	defer me.Done()
-	dep.ensureModPkgIrMetas()
-}
-
-func (me *mainWorker) populateIrMetas(dep *psBowerProject) {
-	defer me.Done()
-	dep.populateModPkgIrMetas()
-}
-
-func (me *mainWorker) prepIrAsts(dep *psBowerProject) {
-	defer me.Done()
-	dep.prepModPkirAsts()
-}
-
-func (me *mainWorker) reGenIrAsts(dep *psBowerProject) {
-	defer me.Done()
-	dep.reGenModPkirAsts()
-}
-
-func (me *mainWorker) writeOutFiles(dep *psBowerProject) {
-	defer me.Done()
-	dep.writeOutFiles()
-}